@@ -2,6 +2,7 @@ package main
 
 import (
 	"BecomeOverMan/internal/handlers"
+	"context"
 	"log"
 	"log/slog"
 
@@ -13,6 +14,7 @@ import (
 	_ "github.com/lib/pq"
 
 	"BecomeOverMan/internal/config"
+	"BecomeOverMan/internal/integrations"
 	_ "BecomeOverMan/internal/models"
 	"BecomeOverMan/internal/repositories"
 	"BecomeOverMan/internal/services"
@@ -34,9 +36,17 @@ func main() {
 	userService := services.NewUserService(userRepo)
 
 	questRepo := repositories.NewQuestRepository(db)
-	questService := services.NewQuestService(questRepo, userRepo)
+	fileStorage := integrations.NewLocalFileStorage(config.Cfg.UploadsDir)
+	questService := services.NewQuestService(questRepo, userRepo, fileStorage)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go questService.RunQuestExpiryWorker(ctx, config.Cfg.QuestExpiryCheckInterval)
+	go questService.RunEventWorker(ctx, config.Cfg.EventCloseCheckInterval)
 
 	r := gin.Default()
+	r.MaxMultipartMemory = config.Cfg.UploadMaxBytes
 	r.Use(cors.New(cors.Config{
 		AllowOrigins:     []string{"*"},
 		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE"},
@@ -0,0 +1,90 @@
+// Сверка балансов монет и опыта пользователей с журналом начислений.
+//
+//	go run ./cmd/reconcile                  # только отчет о расхождениях
+//	go run ./cmd/reconcile -user 42 -json   # отчет по одному пользователю в JSON
+//	go run ./cmd/reconcile -repair=balance  # привести users к сумме по журналу
+//	go run ./cmd/reconcile -repair=ledger   # дописать в журнал корректировки до значений в users
+//
+// Код выхода 1 - остались неисправленные расхождения.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"text/tabwriter"
+
+	"github.com/jmoiron/sqlx"
+	_ "github.com/lib/pq"
+
+	"BecomeOverMan/internal/config"
+	"BecomeOverMan/internal/integrations"
+	"BecomeOverMan/internal/models"
+	"BecomeOverMan/internal/repositories"
+	"BecomeOverMan/internal/services"
+)
+
+func main() {
+	userID := flag.Int("user", 0, "сверить только этого пользователя (0 - всех)")
+	repair := flag.String("repair", "", "исправить расхождения: balance или ledger")
+	asJSON := flag.Bool("json", false, "вывести отчет в JSON")
+	flag.Parse()
+
+	db, err := sqlx.Connect("postgres", config.Cfg.DatabaseURL)
+	if err != nil {
+		log.Fatal("Failed to connect to database:", err)
+	}
+	defer db.Close()
+
+	questRepo := repositories.NewQuestRepository(db)
+	userRepo := repositories.NewUserRepository(db)
+	fileStorage := integrations.NewLocalFileStorage(config.Cfg.UploadsDir)
+	questService := services.NewQuestService(questRepo, userRepo, fileStorage)
+
+	report, err := questService.ReconcileLedger(context.Background(), *userID, *repair)
+	if err != nil {
+		log.Fatal("Ledger reconciliation failed:", err)
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(report); err != nil {
+			log.Fatal(err)
+		}
+	} else {
+		printReport(report)
+	}
+
+	for _, d := range report.Discrepancies {
+		if d.AdjustmentID == nil {
+			os.Exit(1)
+		}
+	}
+}
+
+func printReport(report *models.LedgerReconciliationReport) {
+	fmt.Printf("Ledger reconciliation at %s: %d discrepancies\n",
+		report.CheckedAt.Format("2006-01-02 15:04:05"), len(report.Discrepancies))
+	if len(report.Discrepancies) == 0 {
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "USER\tUSERNAME\tKIND\tBALANCE\tRECORDED\tDELTA\tRESULT")
+	for _, d := range report.Discrepancies {
+		result := "-"
+		switch {
+		case d.Error != "":
+			result = "error: " + d.Error
+		case d.AdjustmentID != nil:
+			result = fmt.Sprintf("repaired (%s, adjustment #%d)", report.Repair, *d.AdjustmentID)
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%d\t%d\t%+d\t%s\n",
+			d.UserID, d.Username, d.Kind, d.Balance, d.Recorded, d.Delta, result)
+	}
+	w.Flush()
+}
@@ -215,4 +215,20 @@ numbered_tasks AS (
 )
 INSERT INTO quest_tasks (quest_id, task_id, task_order)
 SELECT (SELECT id FROM new_quest), id, task_order
-FROM numbered_tasks;
\ No newline at end of file
+FROM numbered_tasks;
+
+-- Ключи квестов для импорта / экспорта бандлов
+UPDATE quests SET external_key = 'quest-' || id WHERE external_key IS NULL;
+
+-- Публикуем первую версию квестов (см. quest_versions)
+INSERT INTO quest_versions (
+    quest_id, version, title, description, category, rarity, difficulty, price, tasks_count,
+    conditions_json, bonus_json, is_sequential, requires_confirmation,
+    window_policy, deadline_policy, reduced_reward_percent, reward_xp, reward_coin, time_limit_hours
+)
+SELECT
+    id, version, title, description, category, rarity, difficulty, price, tasks_count,
+    conditions_json, bonus_json, is_sequential, requires_confirmation,
+    window_policy, deadline_policy, reduced_reward_percent, reward_xp, reward_coin, time_limit_hours
+FROM quests
+ON CONFLICT (quest_id, version) DO NOTHING;
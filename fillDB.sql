@@ -51,4 +51,27 @@ INSERT INTO quest_tasks (quest_id, task_id, task_order) VALUES
 (3, 9, 1),
 (3, 10, 2),
 (3, 11, 3),
-(3, 12, 4);
\ No newline at end of file
+(3, 12, 4);
+
+-- Ключи квестов для импорта / экспорта бандлов
+UPDATE quests SET external_key = 'quest-' || id WHERE external_key IS NULL;
+
+-- Публикуем первую версию квестов (см. quest_versions)
+INSERT INTO quest_versions (
+    quest_id, version, title, description, category, rarity, difficulty, price, tasks_count,
+    conditions_json, bonus_json, is_sequential, requires_confirmation,
+    window_policy, deadline_policy, reduced_reward_percent, reward_xp, reward_coin, time_limit_hours
+)
+SELECT
+    id, version, title, description, category, rarity, difficulty, price, tasks_count,
+    conditions_json, bonus_json, is_sequential, requires_confirmation,
+    window_policy, deadline_policy, reduced_reward_percent, reward_xp, reward_coin, time_limit_hours
+FROM quests
+ON CONFLICT (quest_id, version) DO NOTHING;
+
+-- Предметы магазина
+INSERT INTO shop_items (code, name, description, item_type, price, multiplier, duration_hours) VALUES
+('streak_freeze', 'Заморозка серии', 'Сохраняет серию, если пропустить день', 'streak_freeze', 50, NULL, NULL),
+('xp_booster_24h', 'Усилитель опыта', 'x1.5 опыта за задачи и квесты в течение 24 часов', 'xp_booster', 100, 1.5, 24),
+('deadline_extension_24h', 'Продление срока', 'Продлевает срок начатого квеста на 24 часа', 'deadline_extension', 75, NULL, 24)
+ON CONFLICT (code) DO NOTHING;
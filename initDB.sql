@@ -1,20 +1,37 @@
 -- Удаление таблиц, если они существуют (с правильным порядком и CASCADE)
+DROP TABLE IF EXISTS user_items CASCADE;
+DROP TABLE IF EXISTS shop_items CASCADE;
+DROP TABLE IF EXISTS user_effects CASCADE;
 DROP TABLE IF EXISTS user_achievements CASCADE;
 DROP TABLE IF EXISTS achievements CASCADE;
+DROP TABLE IF EXISTS coin_gifts CASCADE;
+DROP TABLE IF EXISTS ledger_adjustments CASCADE;
 DROP TABLE IF EXISTS user_coin_transactions CASCADE;
 DROP TABLE IF EXISTS user_daily_streaks CASCADE;
+DROP TABLE IF EXISTS task_confirmations CASCADE;
+DROP TABLE IF EXISTS task_submissions CASCADE;
+DROP TABLE IF EXISTS user_task_occurrences CASCADE;
 DROP TABLE IF EXISTS user_tasks CASCADE;
 DROP TABLE IF EXISTS task_variants CASCADE;
 DROP TABLE IF EXISTS tasks CASCADE;
 DROP TABLE IF EXISTS user_completed_quests CASCADE;
 DROP TABLE IF EXISTS user_current_quests CASCADE;
+DROP TABLE IF EXISTS user_quest_attempts CASCADE;
 DROP TABLE IF EXISTS user_quests CASCADE;
 DROP TABLE IF EXISTS quest_tasks CASCADE;
+DROP TABLE IF EXISTS quest_versions CASCADE;
 DROP TABLE IF EXISTS quests CASCADE;
+DROP TABLE IF EXISTS quest_templates CASCADE;
 DROP TABLE IF EXISTS users CASCADE;
 DROP TABLE IF EXISTS categories CASCADE;
 DROP TABLE IF EXISTS friends CASCADE;
 DROP TABLE IF EXISTS shared_quests CASCADE;
+DROP TABLE IF EXISTS reports CASCADE;
+DROP TABLE IF EXISTS quest_reviews CASCADE;
+DROP TABLE IF EXISTS event_results CASCADE;
+DROP TABLE IF EXISTS user_event_points CASCADE;
+DROP TABLE IF EXISTS event_quests CASCADE;
+DROP TABLE IF EXISTS events CASCADE;
 
 -- Удаление типов
 DROP TYPE IF EXISTS category_name CASCADE;
@@ -47,6 +64,13 @@ CREATE TABLE users (
 
     current_streak INT DEFAULT 0,
     longest_streak INT DEFAULT 0,
+    last_streak_date DATE,           -- последний день, засчитанный в серию (см. updateDailyStreak)
+    -- Серия до засчитывания last_streak_date: восстанавливается, если выполнение задачи за этот день отменено
+    prev_current_streak INT,
+    prev_longest_streak INT,
+    prev_streak_date DATE,
+
+    is_moderator BOOLEAN NOT NULL DEFAULT FALSE, -- может проверять квесты и жалобы
 
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
     last_active_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
@@ -64,21 +88,37 @@ CREATE TABLE tasks (
     base_xp_reward INT NOT NULL DEFAULT 0,
     base_coin_reward INT NOT NULL DEFAULT 0,
 
-    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+    type task_type NOT NULL DEFAULT 'special', -- daily / weekly повторяются каждый день / неделю
+    cooldown_hours INT NOT NULL DEFAULT 0,     -- минимальный интервал между выполнениями повторяющейся задачи
+    proof_type VARCHAR(50) NOT NULL DEFAULT 'none', -- требуемое доказательство выполнения: 'none', 'text', 'number', 'file'
 
-    -- type task_type NOT NULL DEFAULT 'daily',
-    -- cooldown_hours INT DEFAULT 24,
+    target_value DOUBLE PRECISION,             -- цель количественной задачи (например, 10000 шагов), NULL - обычная задача
+    unit VARCHAR(50)                           -- единица измерения цели: 'шаги', 'страницы', ...
+);
+
+-- Варианты выполнения задачи (пользователь выбирает один)
+CREATE TABLE task_variants (
+    id SERIAL PRIMARY KEY,
+    task_id INT NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    title VARCHAR(255) NOT NULL,
+    description TEXT,
+    base_xp_reward INT NOT NULL DEFAULT 0,
+    base_coin_reward INT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 );
 
--- Транзакции валюты пользователя
+-- Журнал монет пользователя: каждое изменение users.coin_balance пишется сюда (см. writeCoinTransaction)
 CREATE TABLE user_coin_transactions (
     id SERIAL PRIMARY KEY,
     user_id INT NOT NULL,
     reference_type VARCHAR(50) NOT NULL,
     reference_id INT, -- ID связанной сущности (задача, покупка и т.д.)
 
-    transaction_type VARCHAR(50) NOT NULL, -- 'earned', 'spent', 'bonus'
+    transaction_type VARCHAR(50) NOT NULL, -- 'earned', 'spent', 'bonus', 'reversal', 'refund', 'gift', 'adjustment'
     amount INT NOT NULL,
+    balance_after INT,                      -- users.coin_balance после транзакции
     
     description TEXT,
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
@@ -86,13 +126,42 @@ CREATE TABLE user_coin_transactions (
     FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
 );
 
+CREATE INDEX idx_user_coin_transactions_history ON user_coin_transactions (user_id, id DESC);
+
+-- Корректировки по итогам сверки баланса с журналом (cmd/reconcile), хранятся для аудита
+CREATE TABLE ledger_adjustments (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    kind VARCHAR(10) NOT NULL,   -- 'coins' или 'xp'
+    repair VARCHAR(10) NOT NULL, -- 'balance' (исправлен users) или 'ledger' (дописан журнал)
+    balance INT NOT NULL,        -- значение в users до корректировки
+    recorded INT NOT NULL,       -- сумма по журналу / начислениям до корректировки
+    delta INT NOT NULL,          -- balance - recorded
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_ledger_adjustments_user_id ON ledger_adjustments (user_id);
+
+-- Переводы монет между друзьями (в журнале - две транзакции 'gift' со ссылкой на перевод)
+CREATE TABLE coin_gifts (
+    id SERIAL PRIMARY KEY,
+    sender_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    recipient_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    amount INT NOT NULL CHECK (amount > 0),
+    message VARCHAR(255),
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Для дневного лимита отправителя
+CREATE INDEX idx_coin_gifts_sender_created_at ON coin_gifts (sender_id, created_at);
+
 -- Достижения
 CREATE TABLE achievements (
     id SERIAL PRIMARY KEY,
     name VARCHAR(255) NOT NULL,
     description TEXT,
     criteria_json JSONB NOT NULL, -- например: {"tasks_completed": 100}
-    bonus_json JSONB, -- например заморозка или пассивный бонус
+    bonus_json JSONB, -- пассивный бонус, например [{"type": "xp_multiplier", "multiplier": 1.1}]
     reward_xp INT DEFAULT 0,
     reward_coin INT DEFAULT 0,
     is_secret BOOLEAN DEFAULT FALSE
@@ -109,6 +178,54 @@ CREATE TABLE user_achievements (
     CONSTRAINT unique_user_achievement UNIQUE (user_id, achievement_id)
 );
 
+-- Действующие эффекты пользователя (выдаются из quests.bonus_json и при использовании предметов)
+CREATE TABLE user_effects (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    effect_type VARCHAR(50) NOT NULL,     -- 'xp_multiplier', 'coin_multiplier', 'streak_freeze'
+    multiplier DOUBLE PRECISION,          -- для множителей
+    category VARCHAR(255),                -- для множителя монет по категории (NULL - любая)
+    uses_left INT,                        -- для расходуемых эффектов (заморозки серии)
+    source_type VARCHAR(50) NOT NULL,     -- 'quest', 'achievement', 'item'
+    source_id INT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    expires_at TIMESTAMP                  -- NULL - бессрочно
+);
+
+CREATE INDEX idx_user_effects_user_id ON user_effects (user_id);
+
+-- Каталог расходуемых предметов магазина
+CREATE TABLE shop_items (
+    id SERIAL PRIMARY KEY,
+    code VARCHAR(50) UNIQUE NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    description TEXT,
+    item_type VARCHAR(50) NOT NULL,  -- 'streak_freeze', 'xp_booster', 'deadline_extension'
+    price INT NOT NULL CHECK (price >= 0),
+    multiplier DOUBLE PRECISION,     -- xp_booster: множитель XP
+    duration_hours INT,              -- xp_booster: сколько действует, deadline_extension: на сколько продлевает квест
+    is_active BOOLEAN NOT NULL DEFAULT TRUE
+);
+
+-- Инвентарь пользователя: купленные и еще не использованные предметы
+CREATE TABLE user_items (
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    item_id INT NOT NULL REFERENCES shop_items(id) ON DELETE CASCADE,
+    quantity INT NOT NULL DEFAULT 0 CHECK (quantity >= 0),
+    PRIMARY KEY (user_id, item_id)
+);
+
+-- Шаблоны квестов с параметрами ("Пробежать {distance} км за {days} дней"), см. models.QuestTemplate
+CREATE TABLE quest_templates (
+    id SERIAL PRIMARY KEY,
+    title VARCHAR(255) NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    parameters_json JSONB NOT NULL,        -- параметры: [{name, type, min, max, default, options}]
+    quest_json JSONB NOT NULL,             -- квест и задачи с подстановками {параметр}
+    created_by INT REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
 -- Таблица квестов
 CREATE TABLE quests (
     id SERIAL PRIMARY KEY,
@@ -120,11 +237,64 @@ CREATE TABLE quests (
     price INT NOT NULL DEFAULT 0,
     tasks_count INT DEFAULT 1, -- Сколько задач в квесте
     conditions_json JSONB,
-    bonus_json JSONB,
+    bonus_json JSONB,                       -- эффекты при завершении квеста (см. models.BonusEffect)
     is_sequential BOOLEAN DEFAULT FALSE,   -- Нужно ли выполнять по порядку
+    requires_confirmation BOOLEAN NOT NULL DEFAULT FALSE, -- Выполнение задач подтверждает выбранный друг
+    window_policy VARCHAR(50) NOT NULL DEFAULT 'allow',   -- выполнение вне scheduled_start..scheduled_end: 'allow', 'reduce', 'reject'
+    deadline_policy VARCHAR(50) NOT NULL DEFAULT 'allow', -- выполнение после deadline: 'allow', 'reduce', 'reject'
+    reduced_reward_percent INT NOT NULL DEFAULT 50,       -- процент награды при политике 'reduce'
     reward_xp INT NOT NULL,
     reward_coin INT NOT NULL,
-    time_limit_hours INT DEFAULT 0          -- Ограничение по времени (опционально)
+    time_limit_hours INT DEFAULT 0,         -- Ограничение по времени (опционально)
+    version INT NOT NULL DEFAULT 1,         -- Последняя опубликованная версия (см. quest_versions)
+    external_key VARCHAR(255) UNIQUE,       -- Стабильный ключ для импорта / экспорта бандлов квестов
+    template_id INT REFERENCES quest_templates(id) ON DELETE SET NULL, -- Шаблон, по которому создан квест
+    author_id INT REFERENCES users(id) ON DELETE SET NULL, -- Автор квеста (NULL - системный квест)
+    visibility VARCHAR(20) NOT NULL DEFAULT 'public',      -- Кому виден квест: 'private', 'friends', 'public'
+    -- Модерация пользовательских квестов: 'draft' -> 'submitted' -> 'approved' / 'rejected'
+    moderation_status VARCHAR(20) NOT NULL DEFAULT 'approved',
+    moderation_reason TEXT,                                  -- причина отказа
+    submitted_at TIMESTAMP,                                  -- когда отправлен на проверку
+    moderated_by INT REFERENCES users(id) ON DELETE SET NULL,
+    moderated_at TIMESTAMP,
+    -- Оценки пользователей (см. quest_reviews), обновляются при каждом изменении отзыва
+    rating_sum INT NOT NULL DEFAULT 0,
+    rating_count INT NOT NULL DEFAULT 0,
+    average_rating DOUBLE PRECISION GENERATED ALWAYS AS (
+        CASE WHEN rating_count > 0 THEN rating_sum::DOUBLE PRECISION / rating_count END
+    ) STORED
+);
+
+CREATE INDEX idx_quests_moderation_queue ON quests (submitted_at) WHERE moderation_status = 'submitted';
+
+-- Опубликованные версии квестов (неизменяемые). Начатые квесты пользователей
+-- используют закрепленную версию (user_quests.quest_version), новые покупки - последнюю.
+CREATE TABLE quest_versions (
+    id SERIAL PRIMARY KEY,
+    quest_id INT NOT NULL REFERENCES quests(id) ON DELETE CASCADE,
+    version INT NOT NULL,
+
+    title VARCHAR(255) NOT NULL,
+    description TEXT,
+    category VARCHAR(255) NOT NULL,
+    rarity VARCHAR(255) NOT NULL,
+    difficulty INT NOT NULL DEFAULT 0,
+    price INT NOT NULL DEFAULT 0,
+    tasks_count INT DEFAULT 1,
+    conditions_json JSONB,
+    bonus_json JSONB,
+    is_sequential BOOLEAN DEFAULT FALSE,
+    requires_confirmation BOOLEAN NOT NULL DEFAULT FALSE,
+    window_policy VARCHAR(50) NOT NULL DEFAULT 'allow',
+    deadline_policy VARCHAR(50) NOT NULL DEFAULT 'allow',
+    reduced_reward_percent INT NOT NULL DEFAULT 50,
+    reward_xp INT NOT NULL,
+    reward_coin INT NOT NULL,
+    time_limit_hours INT DEFAULT 0,
+
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT unique_quest_version UNIQUE (quest_id, version)
 );
 
 
@@ -135,21 +305,84 @@ CREATE TABLE user_tasks (
     task_id INT NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
     quest_id INT REFERENCES quests(id) ON DELETE SET NULL,  -- опционально
 
-    status VARCHAR(50) NOT NULL DEFAULT 'active',         -- not_started, active, completed, TODO: failed
+    status VARCHAR(50) NOT NULL DEFAULT 'active',         -- not_started, active, missed (просрочен deadline), pending_confirmation, completed, failed
     scheduled_start TIMESTAMP,
     scheduled_end TIMESTAMP,
     deadline TIMESTAMP,
     duration INT,                                        -- время выделенное на задачу в минутах (?)
     updated_by_ai BOOLEAN DEFAULT FALSE,                 -- была ли запланирована AI
 
-    is_confirmed BOOL DEFAULT FALSE NOT NULL,            -- прежнее поле
+    is_confirmed BOOL DEFAULT FALSE NOT NULL,            -- выполнение подтверждено другом
     completed_at TIMESTAMP,                              -- прежнее поле
     xp_gained INT NOT NULL DEFAULT 0,
     coin_gained INT NOT NULL DEFAULT 0,
+    variant_id INT REFERENCES task_variants(id) ON DELETE SET NULL, -- выбранный вариант задачи
+    progress_value DOUBLE PRECISION NOT NULL DEFAULT 0,  -- прогресс количественной задачи
+    progress_period_start TIMESTAMP,                     -- для daily / weekly: период, к которому относится прогресс
 
     CONSTRAINT unique_user_task UNIQUE (user_id, task_id)
 );
 
+-- Выполнения повторяющихся (daily / weekly) задач: одно на период в рамках попытки квеста
+CREATE TABLE user_task_occurrences (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    task_id INT NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    quest_id INT NOT NULL REFERENCES quests(id) ON DELETE CASCADE,
+    attempt INT NOT NULL DEFAULT 1,        -- user_quests.attempt, в рамках которой выполнено
+
+    period_start TIMESTAMP NOT NULL,
+    period_end TIMESTAMP NOT NULL,
+    status VARCHAR(50) NOT NULL DEFAULT 'pending', -- 'pending' (ждет подтверждения), 'completed'
+    completed_at TIMESTAMP,
+    xp_gained INT NOT NULL DEFAULT 0,
+    coin_gained INT NOT NULL DEFAULT 0,
+
+    CONSTRAINT unique_user_task_occurrence UNIQUE (user_id, task_id, attempt, period_start)
+);
+
+-- Доказательства выполнения задач (текст, число или файл)
+CREATE TABLE task_submissions (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    task_id INT NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    quest_id INT NOT NULL REFERENCES quests(id) ON DELETE CASCADE,
+    attempt INT NOT NULL DEFAULT 1,                  -- user_quests.attempt
+    occurrence_id INT REFERENCES user_task_occurrences(id) ON DELETE SET NULL, -- для повторяющихся задач
+
+    proof_type VARCHAR(50) NOT NULL,
+    text_value TEXT,
+    numeric_value DOUBLE PRECISION,
+    file_key VARCHAR(512),                           -- ключ файла в хранилище
+    file_name VARCHAR(255),
+    content_type VARCHAR(255),
+
+    status VARCHAR(50) NOT NULL DEFAULT 'pending',   -- 'pending', 'accepted', 'rejected' (отклонено другом)
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    accepted_at TIMESTAMP
+);
+
+CREATE INDEX idx_task_submissions_user_quest ON task_submissions (user_id, quest_id);
+
+-- Запросы на подтверждение выполнения задач другом
+CREATE TABLE task_confirmations (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,      -- кто выполнил задачу
+    confirmer_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE, -- кто подтверждает
+    task_id INT NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    quest_id INT NOT NULL REFERENCES quests(id) ON DELETE CASCADE,
+    attempt INT NOT NULL DEFAULT 1,                                   -- user_quests.attempt
+    occurrence_id INT REFERENCES user_task_occurrences(id) ON DELETE SET NULL, -- для повторяющихся задач
+
+    status VARCHAR(50) NOT NULL DEFAULT 'pending',   -- 'pending', 'approved', 'rejected'
+    reward_percent INT NOT NULL DEFAULT 100,         -- процент награды с учетом расписания на момент выполнения
+    reason TEXT,                                     -- причина отказа
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    decided_at TIMESTAMP
+);
+
+CREATE INDEX idx_task_confirmations_confirmer ON task_confirmations (confirmer_id) WHERE status = 'pending';
+
 -- Связь квестов и задач (какие задачи входят в квест)
 CREATE TABLE quest_tasks (
     id SERIAL PRIMARY KEY,
@@ -157,6 +390,8 @@ CREATE TABLE quest_tasks (
     task_id INT NOT NULL,
 
     task_order INT,                        -- Порядок (если is_sequential = TRUE)
+    required_occurrences INT NOT NULL DEFAULT 1, -- Сколько периодов нужно выполнить повторяющуюся задачу
+    version INT NOT NULL DEFAULT 1,        -- Версия квеста, в которую входит задача
 
     FOREIGN KEY (quest_id) REFERENCES quests(id) ON DELETE CASCADE,
     FOREIGN KEY (task_id) REFERENCES tasks(id) ON DELETE CASCADE
@@ -169,16 +404,46 @@ CREATE TABLE user_quests (
     quest_id INT NOT NULL,
 
     status VARCHAR(255) NOT NULL DEFAULT 'purchased', -- "purchased", "started", "failed", "completed"
+    attempt INT NOT NULL DEFAULT 1,                   -- номер текущей попытки (растет при retry)
+    confirmer_id INT REFERENCES users(id) ON DELETE SET NULL, -- друг, подтверждающий выполнение задач
+    quest_version INT NOT NULL DEFAULT 1,             -- версия квеста, купленная пользователем
 
     xp_gained INT,
     coin_gained INT,
 
+    purchased_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP, -- от него отсчитывается окно возврата
     started_at TIMESTAMP,
     completed_at TIMESTAMP,
     expires_at TIMESTAMP,
 
     FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
-    FOREIGN KEY (quest_id) REFERENCES quests(id) ON DELETE CASCADE
+    FOREIGN KEY (quest_id) REFERENCES quests(id) ON DELETE CASCADE,
+    CONSTRAINT unique_user_quest UNIQUE (user_id, quest_id)
+);
+
+-- Для воркера, который проваливает просроченные квесты
+CREATE INDEX idx_user_quests_started_expires_at ON user_quests (expires_at) WHERE status = 'started';
+
+-- История прошлых попыток прохождения квестов (сохраняется при retry)
+CREATE TABLE user_quest_attempts (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    quest_id INT NOT NULL REFERENCES quests(id) ON DELETE CASCADE,
+    attempt INT NOT NULL,
+    quest_version INT NOT NULL DEFAULT 1,  -- версия квеста, которую проходил пользователь
+
+    status VARCHAR(255) NOT NULL,
+    xp_gained INT,
+    coin_gained INT,
+
+    started_at TIMESTAMP,
+    completed_at TIMESTAMP,
+    expires_at TIMESTAMP,
+
+    tasks_snapshot JSONB NOT NULL DEFAULT '[]', -- состояние user_tasks на момент окончания попытки
+    archived_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT unique_user_quest_attempt UNIQUE (user_id, quest_id, attempt)
 );
 
 -- friends
@@ -198,6 +463,88 @@ CREATE TABLE shared_quests (
     quest_id INTEGER NOT NULL REFERENCES quests(id) ON DELETE CASCADE,
     user1_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
     user2_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
-    status VARCHAR(50) DEFAULT 'active',
+    status VARCHAR(50) DEFAULT 'active', -- active, completed, failed
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
-);
\ No newline at end of file
+);
+
+-- Жалобы пользователей на квесты и других пользователей
+CREATE TABLE reports (
+    id SERIAL PRIMARY KEY,
+    reporter_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    quest_id INT REFERENCES quests(id) ON DELETE CASCADE,          -- жалоба на квест
+    reported_user_id INT REFERENCES users(id) ON DELETE CASCADE,   -- или на пользователя
+    reason TEXT NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'open', -- 'open', 'resolved', 'dismissed'
+    resolution TEXT,                            -- комментарий модератора
+    resolved_by INT REFERENCES users(id) ON DELETE SET NULL,
+    resolved_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT report_target CHECK ((quest_id IS NULL) <> (reported_user_id IS NULL))
+);
+
+CREATE INDEX idx_reports_open ON reports (created_at) WHERE status = 'open';
+
+-- Оценки и отзывы пользователей, завершивших квест (один отзыв на квест)
+CREATE TABLE quest_reviews (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    quest_id INT NOT NULL REFERENCES quests(id) ON DELETE CASCADE,
+    quest_version INT NOT NULL,                          -- версия квеста, которую прошел пользователь
+    rating INT NOT NULL CHECK (rating BETWEEN 1 AND 5),
+    review TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT unique_user_quest_review UNIQUE (user_id, quest_id)
+);
+
+CREATE INDEX idx_quest_reviews_quest_id ON quest_reviews (quest_id);
+
+-- Сезонные события: квесты события видны только во время события, за их завершение начисляются очки события.
+-- После ends_at воркер подводит итоги (event_results) и выдает награды за места из rewards_json.
+CREATE TABLE events (
+    id SERIAL PRIMARY KEY,
+    title VARCHAR(255) NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    starts_at TIMESTAMP NOT NULL,
+    ends_at TIMESTAMP NOT NULL,
+    rewards_json JSONB,                     -- награды за места: [{rank_from, rank_to, xp, coins}]
+    closed_at TIMESTAMP,                    -- когда подведены итоги
+    created_by INT REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT event_window CHECK (ends_at > starts_at)
+);
+
+CREATE INDEX idx_events_to_close ON events (ends_at) WHERE closed_at IS NULL;
+
+-- Квесты события (квест принадлежит не больше чем одному событию)
+CREATE TABLE event_quests (
+    event_id INT NOT NULL REFERENCES events(id) ON DELETE CASCADE,
+    quest_id INT NOT NULL UNIQUE REFERENCES quests(id) ON DELETE CASCADE,
+    points INT NOT NULL CHECK (points > 0), -- очки события за завершение квеста
+    PRIMARY KEY (event_id, quest_id)
+);
+
+-- Очки пользователей в событии. reached_at - когда набран текущий счет (при равенстве очков выше тот, кто раньше)
+CREATE TABLE user_event_points (
+    event_id INT NOT NULL REFERENCES events(id) ON DELETE CASCADE,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    points INT NOT NULL DEFAULT 0,
+    reached_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (event_id, user_id)
+);
+
+CREATE INDEX idx_user_event_points_ranking ON user_event_points (event_id, points DESC, reached_at);
+
+-- Итоговая таблица закрытого события с выданными наградами
+CREATE TABLE event_results (
+    event_id INT NOT NULL REFERENCES events(id) ON DELETE CASCADE,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    rank INT NOT NULL,
+    points INT NOT NULL,
+    reward_xp INT NOT NULL DEFAULT 0,
+    reward_coin INT NOT NULL DEFAULT 0,
+    PRIMARY KEY (event_id, user_id)
+);
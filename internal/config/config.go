@@ -3,6 +3,8 @@ package config
 import (
 	"log"
 	"os"
+	"strconv"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -13,6 +15,23 @@ type Config struct {
 	TokenExpireHours                int
 	APIKeyIntelligenceIO            string
 	Recommendation_Service_BASE_URL string
+
+	// Как часто воркер проверяет просроченные квесты
+	QuestExpiryCheckInterval time.Duration
+	// Как часто воркер подводит итоги закончившихся событий
+	EventCloseCheckInterval time.Duration
+	// Цена повторного прохождения проваленного квеста, в процентах от его цены
+	QuestRetryPricePercent int
+	// Сколько времени после выполнения задачи его можно отменить
+	TaskUndoWindow time.Duration
+	// Сколько времени после покупки еще не начатый квест можно вернуть
+	QuestRefundWindow time.Duration
+	// Сколько монет пользователь может перевести друзьям за сутки
+	CoinGiftDailyLimit int
+
+	// Куда сохраняются загруженные файлы (доказательства выполнения задач) и их максимальный размер
+	UploadsDir     string
+	UploadMaxBytes int64
 }
 
 func NewConfig() Config {
@@ -29,7 +48,63 @@ func NewConfig() Config {
 		TokenExpireHours:                24,
 		APIKeyIntelligenceIO:            os.Getenv("API_KEY_INTELLIGENCE_IO"),
 		Recommendation_Service_BASE_URL: "http://localhost:8000/api",
+
+		QuestExpiryCheckInterval: time.Duration(getEnvPositiveInt("QUEST_EXPIRY_CHECK_INTERVAL_SECONDS", 60)) * time.Second,
+		EventCloseCheckInterval:  time.Duration(getEnvPositiveInt("EVENT_CLOSE_CHECK_INTERVAL_SECONDS", 60)) * time.Second,
+		QuestRetryPricePercent:   getEnvNonNegativeInt("QUEST_RETRY_PRICE_PERCENT", 50),
+		TaskUndoWindow:           time.Duration(getEnvInt("TASK_UNDO_WINDOW_SECONDS", 300)) * time.Second,
+		QuestRefundWindow:        time.Duration(getEnvNonNegativeInt("QUEST_REFUND_WINDOW_SECONDS", 86400)) * time.Second,
+		CoinGiftDailyLimit:       getEnvNonNegativeInt("COIN_GIFT_DAILY_LIMIT", 500),
+
+		UploadsDir:     getEnvString("UPLOADS_DIR", "./uploads"),
+		UploadMaxBytes: int64(getEnvInt("UPLOAD_MAX_BYTES", 10<<20)),
 	}
 }
 
+// getEnvString читает строку из переменной окружения, при отсутствии возвращает def
+func getEnvString(key, def string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return def
+}
+
+// getEnvInt читает целое число из переменной окружения, при отсутствии или ошибке возвращает def
+func getEnvInt(key string, def int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return def
+	}
+
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		log.Printf("Invalid value for %s: %q, using default %d", key, value, def)
+		return def
+	}
+
+	return parsed
+}
+
+// getEnvPositiveInt как getEnvInt, но значение должно быть больше нуля (например, интервал тикера)
+func getEnvPositiveInt(key string, def int) int {
+	value := getEnvInt(key, def)
+	if value <= 0 {
+		log.Printf("Invalid value for %s: %d, must be positive, using default %d", key, value, def)
+		return def
+	}
+
+	return value
+}
+
+// getEnvNonNegativeInt как getEnvInt, но отрицательное значение заменяется на def (например, цена или лимит)
+func getEnvNonNegativeInt(key string, def int) int {
+	value := getEnvInt(key, def)
+	if value < 0 {
+		log.Printf("Invalid value for %s: %d, must not be negative, using default %d", key, value, def)
+		return def
+	}
+
+	return value
+}
+
 var Cfg = NewConfig()
@@ -7,7 +7,6 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
-	"log/slog"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -18,6 +17,12 @@ type RequestAI struct {
 }
 
 func (h *QuestHandler) GenerateAIQuest(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
 	// тут из запроса пользователя достаем текст что он написал во фротенде для генерации ему квеста
 	var request RequestAI
 	if err := c.ShouldBindJSON(&request); err != nil {
@@ -32,31 +37,17 @@ func (h *QuestHandler) GenerateAIQuest(c *gin.Context) {
 		return
 	}
 
-	// Сохраняем квест в БД
+	// Сохраняем квест в БД - сгенерированный квест личный, пока автор не откроет его друзьям или всем
+	aiResponse.Quest.AuthorID = &userID
+	aiResponse.Quest.Visibility = models.QuestVisibilityPrivate
+	aiResponse.Quest.IsSequential = true
 	questID, err := h.questService.SaveQuestToDB(aiResponse.Quest, aiResponse.Tasks)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save quest: " + err.Error()})
 		return
 	}
 
-	// параллельно отправляем в Сервис Рекоммендаций
-	req := models.RecommendationService_AddQuests_Request{
-		Quests: []models.RecommendationService_questToAdd{
-			{
-				ID:          questID,
-				Title:       aiResponse.Quest.Title,
-				Description: aiResponse.Quest.Description,
-				Category:    aiResponse.Quest.Category,
-			},
-		},
-	}
-
-	go func() {
-		err := h.sendQuestToRecommendationService(req)
-		if err != nil {
-			slog.Error("Failed to send (add) quest to recommendation service", "error", err)
-		}
-	}()
+	// В Сервис Рекоммендаций квест попадет только после одобрения модератором (см. ReviewQuest)
 
 	// Возвращаем ответ на фронтенд
 	c.JSON(http.StatusOK, gin.H{
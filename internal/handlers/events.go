@@ -0,0 +1,163 @@
+package handlers
+
+import (
+	"BecomeOverMan/internal/models"
+	"BecomeOverMan/internal/services"
+	"BecomeOverMan/pkg/middleware"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetEvents handles GET /events?status=upcoming|active|finished|closed
+func (h *QuestHandler) GetEvents(c *gin.Context) {
+	status := c.Query("status")
+	switch status {
+	case "", models.EventStatusUpcoming, models.EventStatusActive, models.EventStatusFinished, models.EventStatusClosed:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid status. Allowed: upcoming, active, finished, closed"})
+		return
+	}
+
+	events, err := h.questService.GetEvents(c.Request.Context(), status)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, events)
+}
+
+// GetEvent handles GET /events/:eventID — event with its quests, points and the user's place
+func (h *QuestHandler) GetEvent(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	eventID, err := strconv.Atoi(c.Param("eventID"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid event ID"})
+		return
+	}
+
+	event, err := h.questService.GetEvent(c.Request.Context(), eventID, userID)
+	if err != nil {
+		respondEventError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, event)
+}
+
+// GetEventLeaderboard handles GET /events/:eventID/leaderboard?limit=100 — live standings,
+// or final standings with rewards once the event is closed
+func (h *QuestHandler) GetEventLeaderboard(c *gin.Context) {
+	eventID, err := strconv.Atoi(c.Param("eventID"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid event ID"})
+		return
+	}
+
+	limit := 0
+	if q := c.Query("limit"); q != "" {
+		limit, err = strconv.Atoi(q)
+		if err != nil || limit <= 0 || limit > 1000 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit"})
+			return
+		}
+	}
+
+	standings, err := h.questService.GetEventLeaderboard(c.Request.Context(), eventID, limit)
+	if err != nil {
+		respondEventError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, standings)
+}
+
+// CreateEvent handles POST /moderation/events — create a time-limited event with rewards per rank
+func (h *QuestHandler) CreateEvent(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	var req models.CreateEventRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: title, starts_at and ends_at (after starts_at) are required"})
+		return
+	}
+
+	event, err := h.questService.CreateEvent(c.Request.Context(), userID, req)
+	if err != nil {
+		respondEventError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, event)
+}
+
+// AddEventQuest handles POST /moderation/events/:eventID/quests — make a system quest an event quest
+func (h *QuestHandler) AddEventQuest(c *gin.Context) {
+	eventID, err := strconv.Atoi(c.Param("eventID"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid event ID"})
+		return
+	}
+
+	var req models.AddEventQuestRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: quest_id and positive points are required"})
+		return
+	}
+
+	if err := h.questService.AddEventQuest(c.Request.Context(), eventID, req); err != nil {
+		respondEventError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"event_id": eventID, "quest_id": req.QuestID, "points": req.Points})
+}
+
+// RemoveEventQuest handles DELETE /moderation/events/:eventID/quests/:questID
+func (h *QuestHandler) RemoveEventQuest(c *gin.Context) {
+	eventID, err := strconv.Atoi(c.Param("eventID"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid event ID"})
+		return
+	}
+
+	questID, err := strconv.Atoi(c.Param("questID"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid quest ID"})
+		return
+	}
+
+	if err := h.questService.RemoveEventQuest(c.Request.Context(), eventID, questID); err != nil {
+		respondEventError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func respondEventError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvalidEvent), errors.Is(err, services.ErrEventQuestNotSystem):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrEventNotFound),
+		errors.Is(err, services.ErrEventQuestNotFound),
+		errors.Is(err, services.ErrQuestNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrEventClosed), errors.Is(err, services.ErrEventQuestTaken):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,167 @@
+package handlers
+
+import (
+	"BecomeOverMan/internal/models"
+	"BecomeOverMan/internal/services"
+	"BecomeOverMan/pkg/middleware"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SubmitQuest handles POST /quests/:questID/submit — send the author's draft or rejected quest for review
+func (h *QuestHandler) SubmitQuest(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	questID, err := strconv.Atoi(c.Param("questID"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid quest ID"})
+		return
+	}
+
+	if err := h.questService.SubmitQuest(c.Request.Context(), userID, questID); err != nil {
+		respondModerationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"quest_id": questID, "moderation_status": models.QuestModerationSubmitted})
+}
+
+// GetModerationQueue handles GET /moderation/quests — quests waiting for review, oldest first
+func (h *QuestHandler) GetModerationQueue(c *gin.Context) {
+	quests, err := h.questService.GetModerationQueue(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, quests)
+}
+
+// ReviewQuest handles PATCH /moderation/quests/:questID — approve or reject (with a reason) a submitted quest.
+// Approved quests are indexed in the recommendation service.
+func (h *QuestHandler) ReviewQuest(c *gin.Context) {
+	moderatorID, err := middleware.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	questID, err := strconv.Atoi(c.Param("questID"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid quest ID"})
+		return
+	}
+
+	var req models.ReviewQuestRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: status must be approved or rejected, rejection requires a reason"})
+		return
+	}
+
+	quest, err := h.questService.ReviewQuest(c.Request.Context(), moderatorID, questID, req)
+	if err != nil {
+		respondModerationError(c, err)
+		return
+	}
+
+	if quest.ModerationStatus == models.QuestModerationApproved {
+		h.pushQuestToRecommendationService(quest)
+	}
+
+	c.JSON(http.StatusOK, quest)
+}
+
+// CreateReport handles POST /reports — report a quest or another user
+func (h *QuestHandler) CreateReport(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	var req models.CreateReportRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: set either quest_id or user_id and a reason"})
+		return
+	}
+
+	report, err := h.questService.CreateReport(c.Request.Context(), userID, req)
+	if err != nil {
+		respondModerationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, report)
+}
+
+// GetReports handles GET /moderation/reports?status=open|resolved|dismissed
+func (h *QuestHandler) GetReports(c *gin.Context) {
+	status := c.Query("status")
+	switch status {
+	case "", models.ReportOpen, models.ReportResolved, models.ReportDismissed:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid status. Allowed: open, resolved, dismissed"})
+		return
+	}
+
+	reports, err := h.questService.GetReports(c.Request.Context(), status)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, reports)
+}
+
+// ResolveReport handles PATCH /moderation/reports/:reportID — resolve or dismiss an open report
+func (h *QuestHandler) ResolveReport(c *gin.Context) {
+	moderatorID, err := middleware.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	reportID, err := strconv.Atoi(c.Param("reportID"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid report ID"})
+		return
+	}
+
+	var req models.ResolveReportRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: status must be resolved or dismissed"})
+		return
+	}
+
+	report, err := h.questService.ResolveReport(c.Request.Context(), moderatorID, reportID, req)
+	if err != nil {
+		respondModerationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+func respondModerationError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrQuestNotFound),
+		errors.Is(err, services.ErrReportNotFound),
+		errors.Is(err, services.ErrReportedUserNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrQuestForbidden):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrQuestNotSubmittable), errors.Is(err, services.ErrQuestNotSubmitted):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrReportSelf):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,252 @@
+package handlers
+
+import (
+	"BecomeOverMan/internal/models"
+	"BecomeOverMan/internal/services"
+	"BecomeOverMan/pkg/middleware"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CreateQuest handles POST /quests/manual — create a quest with tasks without AI
+func (h *QuestHandler) CreateQuest(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	var req models.SaveQuestRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+		return
+	}
+
+	questID, err := h.questService.CreateQuest(c.Request.Context(), userID, req)
+	if err != nil {
+		respondQuestAuthoringError(c, err)
+		return
+	}
+
+	req.Quest.ID = questID
+
+	// В Сервис Рекоммендаций квест попадет только после одобрения модератором (см. ReviewQuest)
+	c.JSON(http.StatusCreated, gin.H{
+		"message":  "Quest created successfully",
+		"quest_id": questID,
+		"quest":    req.Quest,
+		"tasks":    req.Tasks,
+	})
+}
+
+// UpdateQuest handles PUT /quests/:questID — replace a quest and its tasks, publishing a new version
+func (h *QuestHandler) UpdateQuest(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	questID, err := strconv.Atoi(c.Param("questID"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid quest ID"})
+		return
+	}
+
+	var req models.SaveQuestRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+		return
+	}
+
+	quest, err := h.questService.UpdateQuest(c.Request.Context(), userID, questID, req)
+	if err != nil {
+		respondQuestAuthoringError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, quest)
+}
+
+// DeleteQuest handles DELETE /quests/:questID
+func (h *QuestHandler) DeleteQuest(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	questID, err := strconv.Atoi(c.Param("questID"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid quest ID"})
+		return
+	}
+
+	if err := h.questService.DeleteQuest(c.Request.Context(), userID, questID); err != nil {
+		respondQuestAuthoringError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Quest deleted successfully"})
+}
+
+// AddQuestTask handles POST /quests/:questID/tasks — add a task to a quest
+func (h *QuestHandler) AddQuestTask(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	questID, err := strconv.Atoi(c.Param("questID"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid quest ID"})
+		return
+	}
+
+	var task models.Task
+	if err := c.ShouldBindJSON(&task); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+		return
+	}
+
+	quest, err := h.questService.AddQuestTask(c.Request.Context(), userID, questID, task)
+	if err != nil {
+		respondQuestAuthoringError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, quest)
+}
+
+// UpdateQuestTask handles PUT /quests/:questID/tasks/:taskID — replace a task of a quest
+func (h *QuestHandler) UpdateQuestTask(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	questID, err := strconv.Atoi(c.Param("questID"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid quest ID"})
+		return
+	}
+
+	taskID, err := strconv.Atoi(c.Param("taskID"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID"})
+		return
+	}
+
+	var task models.Task
+	if err := c.ShouldBindJSON(&task); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+		return
+	}
+
+	quest, err := h.questService.UpdateQuestTask(c.Request.Context(), userID, questID, taskID, task)
+	if err != nil {
+		respondQuestAuthoringError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, quest)
+}
+
+// DeleteQuestTask handles DELETE /quests/:questID/tasks/:taskID — remove a task from a quest
+func (h *QuestHandler) DeleteQuestTask(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	questID, err := strconv.Atoi(c.Param("questID"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid quest ID"})
+		return
+	}
+
+	taskID, err := strconv.Atoi(c.Param("taskID"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID"})
+		return
+	}
+
+	quest, err := h.questService.DeleteQuestTask(c.Request.Context(), userID, questID, taskID)
+	if err != nil {
+		respondQuestAuthoringError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, quest)
+}
+
+// SetQuestVisibility handles PATCH /quests/:questID/visibility — make the author's quest private, friends-only or public
+func (h *QuestHandler) SetQuestVisibility(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	questID, err := strconv.Atoi(c.Param("questID"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid quest ID"})
+		return
+	}
+
+	var req models.SetQuestVisibilityRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		return
+	}
+
+	if err := h.questService.SetQuestVisibility(c.Request.Context(), userID, questID, req.Visibility); err != nil {
+		respondQuestAuthoringError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"quest_id": questID, "visibility": req.Visibility})
+}
+
+func respondQuestAuthoringError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvalidQuest):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrQuestNotFound), errors.Is(err, services.ErrQuestTaskNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrQuestForbidden):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrQuestInUse), errors.Is(err, services.ErrQuestVersionConflict):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// pushQuestToRecommendationService параллельно отправляет одобренный квест в Сервис Рекоммендаций
+func (h *QuestHandler) pushQuestToRecommendationService(quest *models.Quest) {
+	req := models.RecommendationService_AddQuests_Request{
+		Quests: []models.RecommendationService_questToAdd{
+			{
+				ID:          quest.ID,
+				Title:       quest.Title,
+				Description: quest.Description,
+				Category:    quest.Category,
+			},
+		},
+	}
+
+	go func() {
+		err := h.sendQuestToRecommendationService(req)
+		if err != nil {
+			slog.Error("Failed to send (add) quest to recommendation service", "error", err, "quest_id", quest.ID)
+		}
+	}()
+}
@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"BecomeOverMan/internal/models"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ExportQuestBundle handles GET /moderation/quest-bundles?ids=1,2&format=json|yaml —
+// download quests (all system quests by default) as a portable bundle
+func (h *QuestHandler) ExportQuestBundle(c *gin.Context) {
+	format, ok := bundleFormat(c)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid format: use json or yaml"})
+		return
+	}
+
+	var questIDs []int
+	if ids := c.Query("ids"); ids != "" {
+		for _, s := range strings.Split(ids, ",") {
+			id, err := strconv.Atoi(strings.TrimSpace(s))
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid quest ID in ids"})
+				return
+			}
+			questIDs = append(questIDs, id)
+		}
+	}
+
+	bundle, err := h.questService.ExportQuestBundle(c.Request.Context(), questIDs)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	data, err := models.MarshalQuestBundle(bundle, format)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	contentType := "application/json"
+	if format == models.BundleFormatYAML {
+		contentType = "application/yaml"
+	}
+	c.Header("Content-Disposition", "attachment; filename=quests."+format)
+	c.Data(http.StatusOK, contentType, data)
+}
+
+// ImportQuestBundle handles POST /moderation/quest-bundles — upload a JSON or YAML bundle
+// (format from ?format= or the yaml Content-Type). Quests are matched by external_key,
+// so importing the same bundle twice changes nothing. Returns a per-quest report.
+func (h *QuestHandler) ImportQuestBundle(c *gin.Context) {
+	format, ok := bundleFormat(c)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid format: use json or yaml"})
+		return
+	}
+
+	data, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read request body"})
+		return
+	}
+
+	bundle, err := models.ParseQuestBundle(data, format)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, changed, err := h.questService.ImportQuestBundle(c.Request.Context(), bundle)
+	for _, quest := range changed {
+		h.pushQuestToRecommendationService(quest)
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error(), "result": result})
+		return
+	}
+
+	status := http.StatusOK
+	if result.Failed > 0 {
+		status = http.StatusMultiStatus
+	}
+	c.JSON(status, result)
+}
+
+// bundleFormat определяет формат бандла по ?format= или Content-Type запроса (по умолчанию json)
+func bundleFormat(c *gin.Context) (string, bool) {
+	format := strings.ToLower(c.Query("format"))
+	switch format {
+	case models.BundleFormatJSON, models.BundleFormatYAML:
+		return format, true
+	case "yml":
+		return models.BundleFormatYAML, true
+	case "":
+		if strings.Contains(c.ContentType(), "yaml") {
+			return models.BundleFormatYAML, true
+		}
+		return models.BundleFormatJSON, true
+	}
+	return "", false
+}
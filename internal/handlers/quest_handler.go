@@ -4,6 +4,7 @@ import (
 	"BecomeOverMan/internal/models"
 	"BecomeOverMan/internal/services"
 	"BecomeOverMan/pkg/middleware"
+	"errors"
 	"net/http"
 	"strconv"
 
@@ -19,7 +20,7 @@ func NewQuestHandler(questService *services.QuestService) *QuestHandler {
 }
 
 type UpdateStatusRequest struct {
-	Status string `json:"status" binding:"required,oneof=purchased active completed"`
+	Status string `json:"status" binding:"required,oneof=purchased active completed retry refunded"`
 }
 
 func (h *QuestHandler) GetQuestDetails(c *gin.Context) {
@@ -38,6 +39,10 @@ func (h *QuestHandler) GetQuestDetails(c *gin.Context) {
 
 	questDetails, err := h.questService.GetQuestDetails(c.Request.Context(), questID, userID)
 	if err != nil {
+		if errors.Is(err, services.ErrQuestNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -45,6 +50,33 @@ func (h *QuestHandler) GetQuestDetails(c *gin.Context) {
 	c.JSON(http.StatusOK, questDetails)
 }
 
+// GetQuestVersions handles GET /quests/:questID/versions — version history of a quest with diffs
+func (h *QuestHandler) GetQuestVersions(c *gin.Context) {
+	questID, err := strconv.Atoi(c.Param("questID"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid quest ID"})
+		return
+	}
+
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	versions, err := h.questService.GetQuestVersions(c.Request.Context(), questID, userID)
+	if err != nil {
+		if errors.Is(err, services.ErrQuestNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, versions)
+}
+
 // GetUserQuests returns user's quests, optionally filtered by ?status=active|completed
 func (h *QuestHandler) GetUserQuests(c *gin.Context) {
 	userID, err := middleware.GetUserID(c)
@@ -89,6 +121,7 @@ func (h *QuestHandler) GetAvailableQuestsHandler(c *gin.Context) {
 	c.JSON(http.StatusOK, quests)
 }
 
+// GetQuestShopHandler handles GET /quests/shop?sort=rating
 func (h *QuestHandler) GetQuestShopHandler(c *gin.Context) {
 	userID, err := middleware.GetUserID(c)
 	if err != nil {
@@ -96,7 +129,7 @@ func (h *QuestHandler) GetQuestShopHandler(c *gin.Context) {
 		return
 	}
 
-	quests, err := h.questService.GetQuestShop(c.Request.Context(), userID)
+	quests, err := h.questService.GetQuestShop(c.Request.Context(), userID, c.Query("sort"))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -105,7 +138,7 @@ func (h *QuestHandler) GetQuestShopHandler(c *gin.Context) {
 	c.JSON(http.StatusOK, quests)
 }
 
-// UpdateQuestStatus handles PATCH /users/me/quests/:questID — purchase, start, or complete a quest
+// UpdateQuestStatus handles PATCH /users/me/quests/:questID — purchase, start, complete, retry or refund a quest
 func (h *QuestHandler) UpdateQuestStatus(c *gin.Context) {
 	userID, err := middleware.GetUserID(c)
 	if err != nil {
@@ -121,7 +154,7 @@ func (h *QuestHandler) UpdateQuestStatus(c *gin.Context) {
 
 	var req UpdateStatusRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: status must be one of: purchased, active, completed"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: status must be one of: purchased, active, completed, retry, refunded"})
 		return
 	}
 
@@ -132,17 +165,59 @@ func (h *QuestHandler) UpdateQuestStatus(c *gin.Context) {
 		err = h.questService.StartQuest(c.Request.Context(), userID, questID)
 	case "completed":
 		err = h.questService.CompleteQuest(c.Request.Context(), userID, questID)
+	case "retry":
+		err = h.questService.RetryQuest(c.Request.Context(), userID, questID)
+	case "refunded":
+		err = h.questService.RefundQuest(c.Request.Context(), userID, questID)
 	}
 
+	if errors.Is(err, services.ErrQuestNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, services.ErrQuestNotRefundable) || errors.Is(err, services.ErrQuestRefundExpired) ||
+		errors.Is(err, services.ErrQuestNotApproved) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"quest_id": questID, "status": req.Status})
+	status := req.Status
+	if status == "retry" {
+		// после retry квест снова в статусе purchased
+		status = "purchased"
+	}
+
+	c.JSON(http.StatusOK, gin.H{"quest_id": questID, "status": status})
+}
+
+// GetQuestAttempts handles GET /users/me/quests/:questID/attempts — history of previous attempts
+func (h *QuestHandler) GetQuestAttempts(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	questID, err := strconv.Atoi(c.Param("questID"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid quest ID"})
+		return
+	}
+
+	attempts, err := h.questService.GetQuestAttempts(c.Request.Context(), userID, questID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, attempts)
 }
 
-// UpdateTaskStatus handles PATCH /users/me/quests/:questID/tasks/:taskID — complete a task
+// UpdateTaskStatus handles PATCH /users/me/quests/:questID/tasks/:taskID — complete a task or undo its completion
 func (h *QuestHandler) UpdateTaskStatus(c *gin.Context) {
 	userID, err := middleware.GetUserID(c)
 	if err != nil {
@@ -168,17 +243,152 @@ func (h *QuestHandler) UpdateTaskStatus(c *gin.Context) {
 		return
 	}
 
+	// Статус active - отмена недавнего выполнения задачи
+	if req.Status == "active" {
+		if err := h.questService.UndoTaskCompletion(c.Request.Context(), userID, questID, taskID); err != nil {
+			if errors.Is(err, services.ErrTaskUndoExpired) || errors.Is(err, services.ErrTaskUndoNotEnoughCoins) ||
+				errors.Is(err, services.ErrTaskUndoLocked) {
+				c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"quest_id": questID, "task_id": taskID, "status": req.Status})
+		return
+	}
+
 	if req.Status != "completed" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid status for task. Allowed: completed"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid status for task. Allowed: completed, active"})
+		return
+	}
+
+	status, err := h.questService.CompleteTask(c.Request.Context(), userID, questID, taskID)
+	if err != nil {
+		if isTaskConflictError(err) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"quest_id": questID, "task_id": taskID, "status": status})
+}
+
+// AddTaskProgress handles POST /users/me/quests/:questID/tasks/:taskID/progress — log progress on a quantitative task.
+// The task is completed automatically once the target is reached.
+func (h *QuestHandler) AddTaskProgress(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	questID, err := strconv.Atoi(c.Param("questID"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid quest ID"})
+		return
+	}
+
+	taskID, err := strconv.Atoi(c.Param("taskID"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID"})
+		return
+	}
+
+	var req models.AddTaskProgressRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		return
+	}
+
+	progress, err := h.questService.AddTaskProgress(c.Request.Context(), userID, questID, taskID, req.Value)
+	if err != nil {
+		if errors.Is(err, services.ErrTaskNotQuantitative) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		// Прогресс сохранен, но выполнить задачу пока нельзя
+		if isTaskConflictError(err) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "progress": progress})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, progress)
+}
+
+// GetActiveEffects handles GET /users/me/effects — effects currently active for the user
+func (h *QuestHandler) GetActiveEffects(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
 		return
 	}
 
-	if err := h.questService.CompleteTask(c.Request.Context(), userID, questID, taskID); err != nil {
+	effects, err := h.questService.GetActiveEffects(c.Request.Context(), userID)
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"quest_id": questID, "task_id": taskID, "status": req.Status})
+	c.JSON(http.StatusOK, effects)
+}
+
+// ChooseTaskVariant handles PATCH /users/me/quests/:questID/tasks/:taskID/variant — pick a task variant
+func (h *QuestHandler) ChooseTaskVariant(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	questID, err := strconv.Atoi(c.Param("questID"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid quest ID"})
+		return
+	}
+
+	taskID, err := strconv.Atoi(c.Param("taskID"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID"})
+		return
+	}
+
+	var req models.ChooseTaskVariantRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		return
+	}
+
+	if err := h.questService.ChooseTaskVariant(c.Request.Context(), userID, questID, taskID, req.VariantID); err != nil {
+		if errors.Is(err, services.ErrTaskVariantNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"quest_id": questID, "task_id": taskID, "variant_id": req.VariantID})
+}
+
+// isTaskConflictError - задачу нельзя выполнить сейчас из-за состояния квеста или задачи
+func isTaskConflictError(err error) bool {
+	return errors.Is(err, services.ErrQuestExpired) ||
+		errors.Is(err, services.ErrTaskLocked) ||
+		errors.Is(err, services.ErrTaskOnCooldown) ||
+		errors.Is(err, services.ErrTaskPeriodCompleted) ||
+		errors.Is(err, services.ErrTaskVariantRequired) ||
+		errors.Is(err, services.ErrProofRequired) ||
+		errors.Is(err, services.ErrConfirmerRequired) ||
+		errors.Is(err, services.ErrTaskTargetNotReached) ||
+		errors.Is(err, services.ErrTaskOutsideWindow) ||
+		errors.Is(err, services.ErrTaskDeadlineMissed)
 }
 
 func (h *QuestHandler) CreateSharedQuest(c *gin.Context) {
@@ -202,7 +412,7 @@ func (h *QuestHandler) CreateSharedQuest(c *gin.Context) {
 	c.JSON(http.StatusCreated, gin.H{"message": "Shared quest created successfully"})
 }
 
-// SearchQuests handles GET /quests/search?q=...&top_k=...&category=...&status=...
+// SearchQuests handles GET /quests/search?q=...&top_k=...&category=...&status=...&sort=rating
 func (h *QuestHandler) SearchQuests(c *gin.Context) {
 	query := c.Query("q")
 	if query == "" {
@@ -230,7 +440,7 @@ func (h *QuestHandler) SearchQuests(c *gin.Context) {
 		return
 	}
 
-	quests, err := h.questService.SearchQuests(c.Request.Context(), req, userID)
+	quests, err := h.questService.SearchQuests(c.Request.Context(), req, userID, c.Query("sort"))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -285,9 +495,20 @@ func RegisterQuestRoutes(router *gin.Engine, questService *services.QuestService
 		questGroup.GET("/shop", handler.GetQuestShopHandler)
 		questGroup.GET("/search", handler.SearchQuests)
 		questGroup.GET("/:questID", handler.GetQuestDetails)
+		questGroup.GET("/:questID/versions", handler.GetQuestVersions)
+		questGroup.GET("/:questID/reviews", handler.GetQuestReviews)
 
 		questGroup.POST("", handler.GenerateAIQuest)
 		questGroup.POST("/shared", handler.CreateSharedQuest)
+
+		questGroup.POST("/manual", handler.CreateQuest)
+		questGroup.PUT("/:questID", handler.UpdateQuest)
+		questGroup.DELETE("/:questID", handler.DeleteQuest)
+		questGroup.PATCH("/:questID/visibility", handler.SetQuestVisibility)
+		questGroup.POST("/:questID/submit", handler.SubmitQuest)
+		questGroup.POST("/:questID/tasks", handler.AddQuestTask)
+		questGroup.PUT("/:questID/tasks/:taskID", handler.UpdateQuestTask)
+		questGroup.DELETE("/:questID/tasks/:taskID", handler.DeleteQuestTask)
 	}
 
 	userQuestsGroup := router.Group("/users/me")
@@ -295,11 +516,70 @@ func RegisterQuestRoutes(router *gin.Engine, questService *services.QuestService
 	{
 		userQuestsGroup.GET("/quests", handler.GetUserQuests)
 		userQuestsGroup.PATCH("/quests/:questID", handler.UpdateQuestStatus)
+		userQuestsGroup.GET("/quests/:questID/attempts", handler.GetQuestAttempts)
+		userQuestsGroup.PUT("/quests/:questID/review", handler.RateQuest)
+		userQuestsGroup.DELETE("/quests/:questID/review", handler.DeleteQuestReview)
 		userQuestsGroup.PATCH("/quests/:questID/tasks/:taskID", handler.UpdateTaskStatus)
+		userQuestsGroup.PATCH("/quests/:questID/tasks/:taskID/variant", handler.ChooseTaskVariant)
+		userQuestsGroup.POST("/quests/:questID/tasks/:taskID/progress", handler.AddTaskProgress)
+		userQuestsGroup.POST("/quests/:questID/tasks/:taskID/submissions", handler.SubmitTaskProof)
+		userQuestsGroup.GET("/submissions/:submissionID/file", handler.GetTaskSubmissionFile)
+		userQuestsGroup.PATCH("/quests/:questID/confirmer", handler.SetQuestConfirmer)
+		userQuestsGroup.GET("/confirmations", handler.GetPendingConfirmations)
+		userQuestsGroup.PATCH("/confirmations/:confirmationID", handler.DecideTaskConfirmation)
+		userQuestsGroup.GET("/effects", handler.GetActiveEffects)
+		userQuestsGroup.GET("/transactions", handler.GetCoinTransactions)
+		userQuestsGroup.GET("/inventory", handler.GetInventory)
+		userQuestsGroup.POST("/inventory/:itemID/use", handler.UseItem)
 		userQuestsGroup.GET("/recommendations/quests", handler.RecommendQuests)
 		userQuestsGroup.GET("/recommendations/friends", handler.RecommendFriends)
 	}
 
+	reportGroup := router.Group("/reports")
+	reportGroup.Use(middleware.JWTAuthMiddleware())
+	{
+		reportGroup.POST("", handler.CreateReport)
+	}
+
+	moderationGroup := router.Group("/moderation")
+	moderationGroup.Use(middleware.JWTAuthMiddleware(), middleware.ModeratorOnlyMiddleware(questService))
+	{
+		moderationGroup.GET("/quests", handler.GetModerationQueue)
+		moderationGroup.PATCH("/quests/:questID", handler.ReviewQuest)
+		moderationGroup.GET("/reports", handler.GetReports)
+		moderationGroup.PATCH("/reports/:reportID", handler.ResolveReport)
+		moderationGroup.GET("/quest-bundles", handler.ExportQuestBundle)
+		moderationGroup.POST("/quest-bundles", handler.ImportQuestBundle)
+		moderationGroup.POST("/quest-templates", handler.CreateQuestTemplate)
+		moderationGroup.DELETE("/quest-templates/:templateID", handler.DeleteQuestTemplate)
+		moderationGroup.POST("/events", handler.CreateEvent)
+		moderationGroup.POST("/events/:eventID/quests", handler.AddEventQuest)
+		moderationGroup.DELETE("/events/:eventID/quests/:questID", handler.RemoveEventQuest)
+	}
+
+	eventGroup := router.Group("/events")
+	eventGroup.Use(middleware.JWTAuthMiddleware())
+	{
+		eventGroup.GET("", handler.GetEvents)
+		eventGroup.GET("/:eventID", handler.GetEvent)
+		eventGroup.GET("/:eventID/leaderboard", handler.GetEventLeaderboard)
+	}
+
+	itemGroup := router.Group("/items")
+	itemGroup.Use(middleware.JWTAuthMiddleware())
+	{
+		itemGroup.GET("", handler.GetShopItems)
+		itemGroup.POST("/:itemID/purchase", handler.PurchaseShopItem)
+	}
+
+	templateGroup := router.Group("/quest-templates")
+	templateGroup.Use(middleware.JWTAuthMiddleware())
+	{
+		templateGroup.GET("", handler.GetQuestTemplates)
+		templateGroup.GET("/:templateID", handler.GetQuestTemplate)
+		templateGroup.POST("/:templateID/quests", handler.InstantiateQuestTemplate)
+	}
+
 	scheduleGroup := router.Group("/schedules")
 	scheduleGroup.Use(middleware.JWTAuthMiddleware())
 	{
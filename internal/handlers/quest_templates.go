@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"BecomeOverMan/internal/models"
+	"BecomeOverMan/internal/services"
+	"BecomeOverMan/pkg/middleware"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetQuestTemplates handles GET /quest-templates — all quest templates with their parameters
+func (h *QuestHandler) GetQuestTemplates(c *gin.Context) {
+	templates, err := h.questService.GetQuestTemplates(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, templates)
+}
+
+// GetQuestTemplate handles GET /quest-templates/:templateID
+func (h *QuestHandler) GetQuestTemplate(c *gin.Context) {
+	templateID, err := strconv.Atoi(c.Param("templateID"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid template ID"})
+		return
+	}
+
+	tmpl, err := h.questService.GetQuestTemplate(c.Request.Context(), templateID)
+	if err != nil {
+		respondQuestTemplateError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, tmpl)
+}
+
+// InstantiateQuestTemplate handles POST /quest-templates/:templateID/quests — create the user's own
+// quest from a template, e.g. {"params": {"distance": 5, "days": 7}}
+func (h *QuestHandler) InstantiateQuestTemplate(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	templateID, err := strconv.Atoi(c.Param("templateID"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid template ID"})
+		return
+	}
+
+	var req models.InstantiateTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+		return
+	}
+
+	quest, err := h.questService.InstantiateQuestTemplate(c.Request.Context(), userID, templateID, req.Params)
+	if err != nil {
+		respondQuestTemplateError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, quest)
+}
+
+// CreateQuestTemplate handles POST /moderation/quest-templates — add a parameterised quest template
+func (h *QuestHandler) CreateQuestTemplate(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	var req models.CreateQuestTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format: " + err.Error()})
+		return
+	}
+
+	tmpl, err := h.questService.CreateQuestTemplate(c.Request.Context(), userID, req)
+	if err != nil {
+		respondQuestTemplateError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, tmpl)
+}
+
+// DeleteQuestTemplate handles DELETE /moderation/quest-templates/:templateID — quests created from it stay
+func (h *QuestHandler) DeleteQuestTemplate(c *gin.Context) {
+	templateID, err := strconv.Atoi(c.Param("templateID"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid template ID"})
+		return
+	}
+
+	if err := h.questService.DeleteQuestTemplate(c.Request.Context(), templateID); err != nil {
+		respondQuestTemplateError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func respondQuestTemplateError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvalidTemplate),
+		errors.Is(err, services.ErrInvalidTemplateParams),
+		errors.Is(err, services.ErrInvalidQuest):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrQuestTemplateNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"BecomeOverMan/internal/models"
+	"BecomeOverMan/internal/services"
+	"BecomeOverMan/pkg/middleware"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetShopItems handles GET /items — catalog of consumable items
+func (h *QuestHandler) GetShopItems(c *gin.Context) {
+	items, err := h.questService.GetShopItems(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, items)
+}
+
+// PurchaseShopItem handles POST /items/:itemID/purchase — buy items for coins, {"quantity": 1} is optional
+func (h *QuestHandler) PurchaseShopItem(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	itemID, err := strconv.Atoi(c.Param("itemID"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid item ID"})
+		return
+	}
+
+	var req models.PurchaseItemRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	item, err := h.questService.PurchaseShopItem(c.Request.Context(), userID, itemID, req)
+	if err != nil {
+		respondShopItemError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, item)
+}
+
+// GetInventory handles GET /users/me/inventory — items the user owns
+func (h *QuestHandler) GetInventory(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	items, err := h.questService.GetInventory(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, items)
+}
+
+// UseItem handles POST /users/me/inventory/:itemID/use — use one item;
+// a deadline extension needs {"quest_id": ...} of a started quest
+func (h *QuestHandler) UseItem(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	itemID, err := strconv.Atoi(c.Param("itemID"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid item ID"})
+		return
+	}
+
+	var req models.UseItemRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	item, err := h.questService.UseItem(c.Request.Context(), userID, itemID, req)
+	if err != nil {
+		respondShopItemError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, item)
+}
+
+func respondShopItemError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrItemQuestRequired):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrShopItemNotFound), errors.Is(err, services.ErrItemNotInInventory):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrNotEnoughCoins), errors.Is(err, services.ErrItemQuestNotExtendable):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
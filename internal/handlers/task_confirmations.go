@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"BecomeOverMan/internal/models"
+	"BecomeOverMan/internal/services"
+	"BecomeOverMan/pkg/middleware"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetQuestConfirmer handles PATCH /users/me/quests/:questID/confirmer — choose a friend who confirms tasks
+func (h *QuestHandler) SetQuestConfirmer(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	questID, err := strconv.Atoi(c.Param("questID"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid quest ID"})
+		return
+	}
+
+	var req models.SetQuestConfirmerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		return
+	}
+
+	if err := h.questService.SetQuestConfirmer(c.Request.Context(), userID, questID, req.FriendID); err != nil {
+		if errors.Is(err, services.ErrConfirmerNotFriend) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"quest_id": questID, "confirmer_id": req.FriendID})
+}
+
+// GetPendingConfirmations handles GET /users/me/confirmations — friends' tasks waiting for my confirmation
+func (h *QuestHandler) GetPendingConfirmations(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	confirmations, err := h.questService.GetPendingConfirmations(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, confirmations)
+}
+
+// DecideTaskConfirmation handles PATCH /users/me/confirmations/:confirmationID — approve or reject a friend's task
+func (h *QuestHandler) DecideTaskConfirmation(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	confirmationID, err := strconv.Atoi(c.Param("confirmationID"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid confirmation ID"})
+		return
+	}
+
+	var req models.DecideTaskConfirmationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		return
+	}
+
+	if err := h.questService.DecideTaskConfirmation(c.Request.Context(), userID, confirmationID, req); err != nil {
+		if errors.Is(err, services.ErrTaskConfirmationNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"confirmation_id": confirmationID, "status": req.Status})
+}
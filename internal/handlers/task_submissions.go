@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"BecomeOverMan/internal/config"
+	"BecomeOverMan/internal/models"
+	"BecomeOverMan/internal/services"
+	"BecomeOverMan/pkg/middleware"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SubmitTaskProof handles POST /users/me/quests/:questID/tasks/:taskID/submissions — attach a proof of completion.
+// Text and numeric proofs are sent as JSON ({"text": ...} / {"value": ...}),
+// files as multipart/form-data with the "file" field.
+func (h *QuestHandler) SubmitTaskProof(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	questID, err := strconv.Atoi(c.Param("questID"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid quest ID"})
+		return
+	}
+
+	taskID, err := strconv.Atoi(c.Param("taskID"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID"})
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, config.Cfg.UploadMaxBytes)
+
+	var req models.SubmitTaskProofRequest
+	var file *models.TaskProofFile
+
+	if strings.HasPrefix(c.ContentType(), "multipart/form-data") {
+		if err := c.ShouldBind(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+			return
+		}
+
+		if header, err := c.FormFile("file"); err == nil {
+			f, err := header.Open()
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read file"})
+				return
+			}
+			defer f.Close()
+
+			file = &models.TaskProofFile{
+				Reader:      f,
+				FileName:    header.Filename,
+				ContentType: header.Header.Get("Content-Type"),
+			}
+		}
+	} else if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		return
+	}
+
+	submission, err := h.questService.SubmitTaskProof(c.Request.Context(), userID, questID, taskID, req, file)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidProof) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if errors.Is(err, services.ErrSubmissionTaskNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, submission)
+}
+
+// GetTaskSubmissionFile handles GET /users/me/submissions/:submissionID/file — download a proof file (own or one the user is asked to confirm)
+func (h *QuestHandler) GetTaskSubmissionFile(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	submissionID, err := strconv.Atoi(c.Param("submissionID"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid submission ID"})
+		return
+	}
+
+	submission, f, err := h.questService.OpenTaskSubmissionFile(c.Request.Context(), userID, submissionID)
+	if err != nil {
+		if errors.Is(err, services.ErrTaskSubmissionNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	defer f.Close()
+
+	contentType := "application/octet-stream"
+	if submission.ContentType != nil && *submission.ContentType != "" {
+		contentType = *submission.ContentType
+	}
+
+	// Имя файла пришло от клиента: FormatMediaType экранирует кавычки и кодирует не-ASCII символы
+	disposition := "attachment"
+	if submission.FileName != nil {
+		if d := mime.FormatMediaType("attachment", map[string]string{"filename": *submission.FileName}); d != "" {
+			disposition = d
+		}
+	}
+	c.Header("Content-Disposition", disposition)
+	c.Header("Content-Type", contentType)
+	c.Status(http.StatusOK)
+	io.Copy(c.Writer, f)
+}
@@ -111,6 +111,48 @@ func (h *UserHandler) GetFriends(c *gin.Context) {
 	c.JSON(http.StatusOK, friends)
 }
 
+// SendCoinGift handles POST /friends/:friendID/gifts — send coins to a friend
+func (h *UserHandler) SendCoinGift(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	friendID, err := strconv.Atoi(c.Param("friendID"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid friend ID"})
+		return
+	}
+
+	var req models.SendCoinGiftRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	gift, err := h.service.SendCoinGift(c.Request.Context(), userID, friendID, req)
+	switch {
+	case errors.Is(err, services.ErrGiftToSelf):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, services.ErrGiftRecipientNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, services.ErrGiftNotFriends):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, services.ErrGiftDailyLimit), errors.Is(err, services.ErrNotEnoughCoins):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gift)
+}
+
 func (h *UserHandler) GetProfile(c *gin.Context) {
 	userID, err := middleware.GetUserID(c)
 	if err != nil {
@@ -260,5 +302,6 @@ func RegisterUserRoutes(router *gin.Engine, userService *services.UserService) {
 	{
 		friendGroup.POST("", handler.AddFriend)
 		friendGroup.GET("", handler.GetFriends)
+		friendGroup.POST("/:friendID/gifts", handler.SendCoinGift)
 	}
 }
@@ -0,0 +1,79 @@
+package integrations
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// FileStorage - хранилище загруженных файлов (доказательства выполнения задач).
+// Сейчас есть только локальная реализация, S3-совместимое хранилище подключается реализацией этого интерфейса.
+type FileStorage interface {
+	Save(ctx context.Context, key string, r io.Reader) error
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// LocalFileStorage хранит файлы на локальном диске в baseDir
+type LocalFileStorage struct {
+	baseDir string
+}
+
+func NewLocalFileStorage(baseDir string) *LocalFileStorage {
+	return &LocalFileStorage{baseDir: baseDir}
+}
+
+func (s *LocalFileStorage) Save(ctx context.Context, key string, r io.Reader) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if _, err := io.Copy(f, r); err != nil {
+		os.Remove(path)
+		return err
+	}
+
+	return f.Close()
+}
+
+func (s *LocalFileStorage) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return os.Open(path)
+}
+
+func (s *LocalFileStorage) Delete(ctx context.Context, key string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	return os.Remove(path)
+}
+
+// path не дает ключу выйти за пределы baseDir
+func (s *LocalFileStorage) path(key string) (string, error) {
+	cleaned := filepath.Clean("/" + key)
+	if strings.Contains(cleaned, "..") {
+		return "", fmt.Errorf("invalid file key: %s", key)
+	}
+
+	return filepath.Join(s.baseDir, cleaned), nil
+}
@@ -0,0 +1,61 @@
+package models
+
+import "time"
+
+// Типы транзакций монет (user_coin_transactions.transaction_type)
+const (
+	CoinTxEarned     = "earned"     // награда за задачу / квест
+	CoinTxSpent      = "spent"      // покупка
+	CoinTxBonus      = "bonus"      // награда за место в событии и т.п.
+	CoinTxReversal   = "reversal"   // отмена ранее начисленной награды
+	CoinTxRefund     = "refund"     // возврат цены непройденного квеста
+	CoinTxGift       = "gift"       // перевод монет другу (< 0 у отправителя, > 0 у получателя)
+	CoinTxAdjustment = "adjustment" // корректировка по итогам сверки (см. ledger_adjustments)
+)
+
+// Типы сущностей, к которым относится транзакция (user_coin_transactions.reference_type)
+const (
+	CoinRefTask        = "task"
+	CoinRefQuest       = "quest"
+	CoinRefQuestRetry  = "quest_retry"
+	CoinRefSharedQuest = "shared_quest"
+	CoinRefEvent       = "event"
+	CoinRefAdjustment  = "ledger_adjustment"
+	CoinRefGift        = "coin_gift"
+	CoinRefShopItem    = "shop_item"
+)
+
+const (
+	DefaultCoinTransactionsLimit = 50
+	MaxCoinTransactionsLimit     = 200
+)
+
+// CoinTransaction - запись журнала монет. Каждое изменение users.coin_balance записывается в журнал.
+type CoinTransaction struct {
+	ID              int       `json:"id" db:"id"`
+	UserID          int       `json:"user_id" db:"user_id"`
+	ReferenceType   string    `json:"reference_type" db:"reference_type"`
+	ReferenceID     *int      `json:"reference_id" db:"reference_id"`
+	TransactionType string    `json:"transaction_type" db:"transaction_type"`
+	Amount          int       `json:"amount" db:"amount"`               // > 0 - начисление, < 0 - списание
+	BalanceAfter    *int      `json:"balance_after" db:"balance_after"` // баланс после транзакции
+	Description     *string   `json:"description" db:"description"`
+	CreatedAt       time.Time `json:"created_at" db:"created_at"`
+}
+
+// CoinTransactionsFilter - фильтры и курсор истории транзакций (GET /users/me/transactions)
+type CoinTransactionsFilter struct {
+	Type          string     `form:"type"`
+	ReferenceType string     `form:"reference_type"`
+	ReferenceID   *int       `form:"reference_id"`
+	From          *time.Time `form:"from" time_format:"2006-01-02T15:04:05Z07:00"`
+	To            *time.Time `form:"to" time_format:"2006-01-02T15:04:05Z07:00"`
+	Cursor        int        `form:"cursor" binding:"min=0"` // id последней полученной транзакции
+	Limit         int        `form:"limit" binding:"min=0,max=200"`
+}
+
+// CoinTransactionsPage - страница истории транзакций (от новых к старым)
+type CoinTransactionsPage struct {
+	Transactions []CoinTransaction `json:"transactions"`
+	NextCursor   *int              `json:"next_cursor"` // nil - больше транзакций нет
+}
@@ -0,0 +1,125 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"time"
+)
+
+// Типы эффектов, которые можно описать в quests.bonus_json и achievements.bonus_json
+const (
+	// Множитель XP на duration_hours часов (для достижений - постоянно)
+	EffectXPMultiplier = "xp_multiplier"
+	// Множитель монет, опционально только для наград категории category
+	EffectCoinMultiplier = "coin_multiplier"
+	// count заморозок серии (current_streak)
+	EffectStreakFreeze = "streak_freeze"
+	// Доп. награда xp/coins, если квест завершен не позже чем через within_hours часов после старта
+	EffectEarlyCompletion = "early_completion"
+)
+
+// BonusEffect - одно описание эффекта из bonus_json.
+//
+// bonus_json - это массив эффектов, например:
+//
+//	[
+//		{"type": "xp_multiplier", "multiplier": 1.5, "duration_hours": 24},
+//		{"type": "coin_multiplier", "multiplier": 2, "category": "health", "duration_hours": 72},
+//		{"type": "streak_freeze", "count": 1},
+//		{"type": "early_completion", "within_hours": 48, "xp": 100, "coins": 50}
+//	]
+type BonusEffect struct {
+	Type          string  `json:"type"`
+	Multiplier    float64 `json:"multiplier,omitempty"`
+	Category      string  `json:"category,omitempty"`
+	DurationHours int     `json:"duration_hours,omitempty"`
+	Count         int     `json:"count,omitempty"`
+	WithinHours   int     `json:"within_hours,omitempty"`
+	XP            int     `json:"xp,omitempty"`
+	Coins         int     `json:"coins,omitempty"`
+}
+
+// ParseBonusEffects разбирает bonus_json. Пустое значение означает отсутствие эффектов.
+func ParseBonusEffects(raw *json.RawMessage) ([]BonusEffect, error) {
+	if raw == nil || len(*raw) == 0 || string(*raw) == "null" {
+		return nil, nil
+	}
+
+	var effects []BonusEffect
+	if err := json.Unmarshal(*raw, &effects); err != nil {
+		return nil, fmt.Errorf("invalid bonus_json: %w", err)
+	}
+
+	for _, e := range effects {
+		if err := e.Validate(); err != nil {
+			return nil, err
+		}
+	}
+
+	return effects, nil
+}
+
+// Validate проверяет, что у эффекта заданы все нужные для его типа параметры
+func (e BonusEffect) Validate() error {
+	switch e.Type {
+	case EffectXPMultiplier, EffectCoinMultiplier:
+		if e.Multiplier <= 0 {
+			return fmt.Errorf("invalid bonus_json: %s requires positive multiplier", e.Type)
+		}
+		if e.DurationHours < 0 {
+			return fmt.Errorf("invalid bonus_json: %s duration_hours can't be negative", e.Type)
+		}
+	case EffectStreakFreeze:
+		if e.Count <= 0 {
+			return fmt.Errorf("invalid bonus_json: %s requires positive count", e.Type)
+		}
+	case EffectEarlyCompletion:
+		if e.WithinHours <= 0 {
+			return fmt.Errorf("invalid bonus_json: %s requires positive within_hours", e.Type)
+		}
+		if e.XP < 0 || e.Coins < 0 {
+			return fmt.Errorf("invalid bonus_json: %s rewards can't be negative", e.Type)
+		}
+	default:
+		return fmt.Errorf("invalid bonus_json: unknown effect type %q", e.Type)
+	}
+
+	return nil
+}
+
+// ActiveEffect - эффект, действующий на пользователя сейчас
+type ActiveEffect struct {
+	ID         int        `json:"id,omitempty" db:"id"`
+	UserID     int        `json:"user_id" db:"user_id"`
+	EffectType string     `json:"effect_type" db:"effect_type"`
+	Multiplier *float64   `json:"multiplier,omitempty" db:"multiplier"`
+	Category   *string    `json:"category,omitempty" db:"category"`
+	UsesLeft   *int       `json:"uses_left,omitempty" db:"uses_left"`
+	SourceType string     `json:"source_type" db:"source_type"` // quest, achievement
+	SourceID   *int       `json:"source_id,omitempty" db:"source_id"`
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty" db:"expires_at"` // NULL - бессрочно
+}
+
+// ApplyRewardMultipliers применяет к награде действующие множители. Множители перемножаются.
+// Множитель монет с category действует только на награды этой категории.
+func ApplyRewardMultipliers(effects []ActiveEffect, category string, xp, coins int) (int, int) {
+	xpMultiplier, coinMultiplier := 1.0, 1.0
+
+	for _, e := range effects {
+		if e.Multiplier == nil {
+			continue
+		}
+		switch e.EffectType {
+		case EffectXPMultiplier:
+			xpMultiplier *= *e.Multiplier
+		case EffectCoinMultiplier:
+			if e.Category == nil || *e.Category == "" || *e.Category == category {
+				coinMultiplier *= *e.Multiplier
+			}
+		}
+	}
+
+	return int(math.Round(float64(xp) * xpMultiplier)), int(math.Round(float64(coins) * coinMultiplier))
+}
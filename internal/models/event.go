@@ -0,0 +1,137 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+var ErrInvalidEvent = errors.New("invalid event")
+
+// Состояние события относительно его окна starts_at..ends_at
+const (
+	EventStatusUpcoming = "upcoming"
+	EventStatusActive   = "active"
+	EventStatusFinished = "finished" // окно закончилось, итоги еще не подведены
+	EventStatusClosed   = "closed"   // итоги подведены, награды выданы
+)
+
+// Event - ограниченное по времени событие ("Месяц силы воли") со своими квестами, очками и таблицей лидеров.
+// Квесты события видны в магазине и доступных квестах только во время события.
+type Event struct {
+	ID          int              `json:"id" db:"id"`
+	Title       string           `json:"title" db:"title"`
+	Description string           `json:"description" db:"description"`
+	StartsAt    time.Time        `json:"starts_at" db:"starts_at"`
+	EndsAt      time.Time        `json:"ends_at" db:"ends_at"`
+	RewardsJson *json.RawMessage `json:"-" db:"rewards_json"`
+	ClosedAt    *time.Time       `json:"closed_at,omitempty" db:"closed_at"`
+	CreatedBy   *int             `json:"created_by,omitempty" db:"created_by"`
+	CreatedAt   time.Time        `json:"created_at" db:"created_at"`
+
+	Status     string         `json:"status" db:"-"`
+	Rewards    []EventReward  `json:"rewards" db:"-"`
+	Quests     []EventQuest   `json:"quests,omitempty" db:"-"`
+	MyStanding *EventStanding `json:"my_standing,omitempty" db:"-"`
+}
+
+// EventReward - награда за места с RankFrom по RankTo (включительно) в итоговой таблице события
+type EventReward struct {
+	RankFrom int `json:"rank_from" binding:"min=1"`
+	RankTo   int `json:"rank_to" binding:"min=1"`
+	XP       int `json:"xp" binding:"min=0"`
+	Coins    int `json:"coins" binding:"min=0"`
+}
+
+// EventQuest - квест события и очки события за его завершение
+type EventQuest struct {
+	QuestID  int    `json:"quest_id" db:"quest_id"`
+	Title    string `json:"title" db:"title"`
+	Category string `json:"category" db:"category"`
+	Rarity   string `json:"rarity" db:"rarity"`
+	Price    int    `json:"price" db:"price"`
+	Points   int    `json:"points" db:"points"`
+}
+
+// EventStanding - строка таблицы лидеров. Награда заполняется после закрытия события.
+type EventStanding struct {
+	Rank       int    `json:"rank" db:"rank"`
+	UserID     int    `json:"user_id" db:"user_id"`
+	Username   string `json:"username" db:"username"`
+	Points     int    `json:"points" db:"points"`
+	RewardXP   int    `json:"reward_xp,omitempty" db:"reward_xp"`
+	RewardCoin int    `json:"reward_coin,omitempty" db:"reward_coin"`
+}
+
+type CreateEventRequest struct {
+	Title       string        `json:"title" binding:"required"`
+	Description string        `json:"description"`
+	StartsAt    time.Time     `json:"starts_at" binding:"required"`
+	EndsAt      time.Time     `json:"ends_at" binding:"required,gtfield=StartsAt"`
+	Rewards     []EventReward `json:"rewards" binding:"dive"`
+}
+
+type AddEventQuestRequest struct {
+	QuestID int `json:"quest_id" binding:"required"`
+	Points  int `json:"points" binding:"required,min=1"`
+}
+
+// Decode разбирает rewards_json и вычисляет статус события на момент now
+func (e *Event) Decode(now time.Time) error {
+	e.Rewards = []EventReward{}
+	if e.RewardsJson != nil {
+		if err := json.Unmarshal(*e.RewardsJson, &e.Rewards); err != nil {
+			return fmt.Errorf("event %d rewards: %w", e.ID, err)
+		}
+	}
+
+	switch {
+	case e.ClosedAt != nil:
+		e.Status = EventStatusClosed
+	case now.Before(e.StartsAt):
+		e.Status = EventStatusUpcoming
+	case now.Before(e.EndsAt):
+		e.Status = EventStatusActive
+	default:
+		e.Status = EventStatusFinished
+	}
+	return nil
+}
+
+// ValidateEventRewards проверяет, что диапазоны мест наград корректны и не пересекаются
+func ValidateEventRewards(rewards []EventReward) error {
+	var problems []string
+	sorted := slices.Clone(rewards)
+	slices.SortFunc(sorted, func(a, b EventReward) int { return a.RankFrom - b.RankFrom })
+
+	for i, r := range sorted {
+		if r.RankFrom < 1 || r.RankTo < r.RankFrom {
+			problems = append(problems, fmt.Sprintf("ranks %d-%d: rank_from must be >= 1 and <= rank_to", r.RankFrom, r.RankTo))
+		}
+		if r.XP < 0 || r.Coins < 0 {
+			problems = append(problems, fmt.Sprintf("ranks %d-%d: rewards can't be negative", r.RankFrom, r.RankTo))
+		}
+		if i > 0 && r.RankFrom <= sorted[i-1].RankTo {
+			problems = append(problems, fmt.Sprintf("ranks %d-%d overlap with %d-%d",
+				r.RankFrom, r.RankTo, sorted[i-1].RankFrom, sorted[i-1].RankTo))
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("%w: %s", ErrInvalidEvent, strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// EventRewardFor возвращает награду за место rank (0, 0 - место без награды)
+func EventRewardFor(rewards []EventReward, rank int) (xp, coins int) {
+	for _, r := range rewards {
+		if rank >= r.RankFrom && rank <= r.RankTo {
+			return r.XP, r.Coins
+		}
+	}
+	return 0, 0
+}
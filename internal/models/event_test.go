@@ -0,0 +1,98 @@
+package models
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestValidateEventRewards(t *testing.T) {
+	tests := []struct {
+		name    string
+		rewards []EventReward
+		want    string // подстрока ошибки, "" - награды корректны
+	}{
+		{
+			name: "no rewards",
+		},
+		{
+			name: "adjacent ranges in any order",
+			rewards: []EventReward{
+				{RankFrom: 4, RankTo: 10, XP: 50, Coins: 10},
+				{RankFrom: 1, RankTo: 1, XP: 500, Coins: 100},
+				{RankFrom: 2, RankTo: 3, XP: 200, Coins: 50},
+			},
+		},
+		{
+			name:    "rank_from below 1",
+			rewards: []EventReward{{RankFrom: 0, RankTo: 3}},
+			want:    "ranks 0-3: rank_from must be >= 1 and <= rank_to",
+		},
+		{
+			name:    "reversed range",
+			rewards: []EventReward{{RankFrom: 5, RankTo: 2}},
+			want:    "ranks 5-2: rank_from must be >= 1 and <= rank_to",
+		},
+		{
+			name:    "negative reward",
+			rewards: []EventReward{{RankFrom: 1, RankTo: 1, Coins: -10}},
+			want:    "ranks 1-1: rewards can't be negative",
+		},
+		{
+			name: "overlapping ranges",
+			rewards: []EventReward{
+				{RankFrom: 3, RankTo: 10},
+				{RankFrom: 1, RankTo: 3},
+			},
+			want: "ranks 3-10 overlap with 1-3",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateEventRewards(tt.rewards)
+			if tt.want == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+
+			if !errors.Is(err, ErrInvalidEvent) {
+				t.Fatalf("error = %v, want ErrInvalidEvent", err)
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error = %q, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestEventRewardFor(t *testing.T) {
+	rewards := []EventReward{
+		{RankFrom: 1, RankTo: 1, XP: 500, Coins: 100},
+		{RankFrom: 2, RankTo: 3, XP: 200, Coins: 50},
+	}
+
+	tests := []struct {
+		rank      int
+		wantXP    int
+		wantCoins int
+	}{
+		{rank: 1, wantXP: 500, wantCoins: 100},
+		{rank: 2, wantXP: 200, wantCoins: 50},
+		{rank: 3, wantXP: 200, wantCoins: 50},
+		{rank: 4, wantXP: 0, wantCoins: 0},
+	}
+
+	for _, tt := range tests {
+		xp, coins := EventRewardFor(rewards, tt.rank)
+		if xp != tt.wantXP || coins != tt.wantCoins {
+			t.Errorf("EventRewardFor(rank %d) = %d, %d, want %d, %d", tt.rank, xp, coins, tt.wantXP, tt.wantCoins)
+		}
+	}
+
+	if xp, coins := EventRewardFor(nil, 1); xp != 0 || coins != 0 {
+		t.Errorf("EventRewardFor(nil, 1) = %d, %d, want 0, 0", xp, coins)
+	}
+}
@@ -29,3 +29,18 @@ type AddFriendRequest struct {
 	FriendID   *int    `json:"friend_id"`
 	FriendName *string `json:"friend_name"`
 }
+
+// CoinGift - перевод монет другу
+type CoinGift struct {
+	ID          int       `json:"id" db:"id"`
+	SenderID    int       `json:"sender_id" db:"sender_id"`
+	RecipientID int       `json:"recipient_id" db:"recipient_id"`
+	Amount      int       `json:"amount" db:"amount"`
+	Message     *string   `json:"message" db:"message"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+}
+
+type SendCoinGiftRequest struct {
+	Amount  int     `json:"amount" binding:"required,min=1"`
+	Message *string `json:"message" binding:"omitempty,max=255"`
+}
@@ -0,0 +1,49 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+// Что сверяется: монеты (users.coin_balance с user_coin_transactions)
+// и опыт (users.xp_points с начислениями в user_tasks, user_quests, user_quest_attempts, event_results)
+const (
+	LedgerKindCoins = "coins"
+	LedgerKindXP    = "xp"
+)
+
+// Способ исправления расхождения
+const (
+	LedgerRepairBalance = "balance" // users приводится к сумме по журналу
+	LedgerRepairLedger  = "ledger"  // в журнал дописывается корректировка до значения в users
+)
+
+var ErrInvalidLedgerRepair = errors.New("repair must be \"balance\" or \"ledger\"")
+
+// LedgerDiscrepancy - расхождение значения в users с суммой записанных начислений
+type LedgerDiscrepancy struct {
+	UserID   int    `json:"user_id" db:"user_id"`
+	Username string `json:"username" db:"username"`
+	Kind     string `json:"kind" db:"kind"`
+	Balance  int    `json:"balance" db:"balance"`   // значение в users
+	Recorded int    `json:"recorded" db:"recorded"` // сумма по журналу / начислениям
+	Delta    int    `json:"delta" db:"delta"`       // balance - recorded
+
+	AdjustmentID *int   `json:"adjustment_id,omitempty" db:"-"` // запись ledger_adjustments, если исправлено
+	Error        string `json:"error,omitempty" db:"-"`
+}
+
+// LedgerReconciliationReport - результат сверки
+type LedgerReconciliationReport struct {
+	CheckedAt     time.Time           `json:"checked_at"`
+	Repair        string              `json:"repair,omitempty"` // пусто - только отчет
+	Discrepancies []LedgerDiscrepancy `json:"discrepancies"`
+}
+
+func ValidateLedgerRepair(repair string) error {
+	switch repair {
+	case "", LedgerRepairBalance, LedgerRepairLedger:
+		return nil
+	}
+	return ErrInvalidLedgerRepair
+}
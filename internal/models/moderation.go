@@ -0,0 +1,49 @@
+package models
+
+import "time"
+
+// Статусы модерации квеста (quests.moderation_status)
+const (
+	QuestModerationDraft     = "draft"     // автор еще редактирует квест
+	QuestModerationSubmitted = "submitted" // ждет проверки модератором
+	QuestModerationApproved  = "approved"  // одобрен: виден другим пользователям и проиндексирован
+	QuestModerationRejected  = "rejected"  // отклонен с причиной, автор может исправить и отправить снова
+)
+
+// Статусы жалобы (reports.status)
+const (
+	ReportOpen      = "open"
+	ReportResolved  = "resolved"
+	ReportDismissed = "dismissed"
+)
+
+type ReviewQuestRequest struct {
+	Status string  `json:"status" binding:"required,oneof=approved rejected"`
+	Reason *string `json:"reason" binding:"required_if=Status rejected"`
+}
+
+// Report - жалоба пользователя на квест или другого пользователя
+type Report struct {
+	ID             int        `json:"id" db:"id"`
+	ReporterID     int        `json:"reporter_id" db:"reporter_id"`
+	QuestID        *int       `json:"quest_id,omitempty" db:"quest_id"`
+	ReportedUserID *int       `json:"reported_user_id,omitempty" db:"reported_user_id"`
+	Reason         string     `json:"reason" db:"reason"`
+	Status         string     `json:"status" db:"status"`
+	Resolution     *string    `json:"resolution,omitempty" db:"resolution"`
+	ResolvedBy     *int       `json:"resolved_by,omitempty" db:"resolved_by"`
+	ResolvedAt     *time.Time `json:"resolved_at,omitempty" db:"resolved_at"`
+	CreatedAt      time.Time  `json:"created_at" db:"created_at"`
+}
+
+// CreateReportRequest - жалоба на квест (quest_id) или пользователя (user_id), ровно одно из них
+type CreateReportRequest struct {
+	QuestID *int   `json:"quest_id" binding:"required_without=UserID,excluded_with=UserID"`
+	UserID  *int   `json:"user_id" binding:"required_without=QuestID"`
+	Reason  string `json:"reason" binding:"required"`
+}
+
+type ResolveReportRequest struct {
+	Status     string  `json:"status" binding:"required,oneof=resolved dismissed"`
+	Resolution *string `json:"resolution"`
+}
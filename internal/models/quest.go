@@ -25,7 +25,44 @@ type Quest struct {
 	RewardXP       int              `json:"reward_xp" db:"reward_xp"`
 	RewardCoin     int              `json:"reward_coin" db:"reward_coin"`
 	TimeLimitHours int              `json:"time_limit_hours" db:"time_limit_hours"`
+	Version        int              `json:"version" db:"version"` // версия определения квеста (у начатого квеста - закрепленная)
 	Tasks          []Task           `json:"tasks,omitempty"`
+
+	// Стабильный ключ квеста для импорта / экспорта бандлов (см. QuestBundle)
+	ExternalKey *string `json:"external_key,omitempty" db:"external_key"`
+	// Шаблон, по которому создан квест (см. QuestTemplate)
+	TemplateID *int `json:"template_id,omitempty" db:"template_id"`
+
+	// Автор квеста (nil - системный квест) и кому квест виден (см. QuestVisibility*)
+	AuthorID   *int   `json:"author_id" db:"author_id"`
+	Visibility string `json:"visibility" db:"visibility"`
+
+	// Модерация пользовательских квестов (см. QuestModeration*)
+	ModerationStatus string     `json:"moderation_status" db:"moderation_status"`
+	ModerationReason *string    `json:"moderation_reason,omitempty" db:"moderation_reason"`
+	SubmittedAt      *time.Time `json:"submitted_at,omitempty" db:"submitted_at"`
+	ModeratedBy      *int       `json:"moderated_by,omitempty" db:"moderated_by"`
+	ModeratedAt      *time.Time `json:"moderated_at,omitempty" db:"moderated_at"`
+
+	// Оценки прошедших квест пользователей (агрегаты обновляются вместе с отзывами)
+	RatingSum     int      `json:"-" db:"rating_sum"`
+	RatingCount   int      `json:"rating_count" db:"rating_count"`
+	AverageRating *float64 `json:"average_rating" db:"average_rating"` // nil - оценок еще нет
+
+	// Выполнение задач подтверждает выбранный пользователем друг, награда - только после одобрения
+	RequiresConfirmation bool `json:"requires_confirmation" db:"requires_confirmation"`
+
+	// Что делать с задачей, выполненной вне запланированного окна / после дедлайна (см. SchedulePolicy*)
+	WindowPolicy         string `json:"window_policy" db:"window_policy"`
+	DeadlinePolicy       string `json:"deadline_policy" db:"deadline_policy"`
+	ReducedRewardPercent int    `json:"reduced_reward_percent" db:"reduced_reward_percent"`
+
+	// Для последовательного квеста - задача, которую пользователь может выполнить сейчас
+	UnlockedTaskID *int `json:"unlocked_task_id,omitempty" db:"-"`
+
+	// Результат проверки conditions_json для текущего пользователя
+	IsLocked      bool     `json:"is_locked" db:"-"`
+	LockedReasons []string `json:"locked_reasons,omitempty" db:"-"`
 }
 
 type Task struct {
@@ -40,9 +77,20 @@ type Task struct {
 	TaskOrder      int       `json:"task_order" db:"task_order"`
 	CreatedAt      time.Time `json:"created_at" db:"created_at"`
 
+	// --- повторяющиеся задачи (daily / weekly)
+	Type                string `json:"type" db:"type"` // daily, weekly, special, user_generated
+	CooldownHours       int    `json:"cooldown_hours" db:"cooldown_hours"`
+	RequiredOccurrences int    `json:"required_occurrences" db:"required_occurrences"` // сколько периодов нужно выполнить в квесте
+
+	ProofType string `json:"proof_type" db:"proof_type"` // none, text, number, file
+
+	// --- количественные задачи (например, 10000 шагов)
+	TargetValue *float64 `json:"target_value,omitempty" db:"target_value"`
+	Unit        *string  `json:"unit,omitempty" db:"unit"`
+
 	// --- опциональные поля для UserTask
 	QuestID        *int       `json:"quest_id" db:"quest_id"`
-	Status         *string    `json:"status" db:"status"` // nullable
+	Status         *string    `json:"status" db:"status"` // nullable; not_started, active, missed, pending_confirmation, completed, failed
 	ScheduledStart *time.Time `json:"scheduled_start" db:"scheduled_start"`
 	ScheduledEnd   *time.Time `json:"scheduled_end" db:"scheduled_end"`
 	Deadline       *time.Time `json:"deadline" db:"deadline"`
@@ -53,12 +101,25 @@ type Task struct {
 
 	XpGained   *int `json:"xp_gained" db:"xp_gained"`     // nullable
 	CoinGained *int `json:"coin_gained" db:"coin_gained"` // nullable
+
+	// Варианты выполнения задачи и выбранный пользователем вариант
+	Variants  []TaskVariant `json:"variants,omitempty" db:"-"`
+	VariantID *int          `json:"variant_id" db:"variant_id"`
+
+	// Доказательства выполнения задачи пользователем (текущая попытка)
+	Submissions []TaskSubmission `json:"submissions,omitempty" db:"-"`
+
+	OccurrencesDone   *int  `json:"occurrences_done,omitempty" db:"occurrences_done"`       // выполнено периодов в текущей попытке
+	CurrentPeriodDone *bool `json:"current_period_done,omitempty" db:"current_period_done"` // выполнена ли задача в текущем периоде
+
+	ProgressValue   *float64 `json:"progress_value,omitempty" db:"progress_value"`     // прогресс количественной задачи (для daily / weekly - за текущий период)
+	ProgressPercent *float64 `json:"progress_percent,omitempty" db:"progress_percent"` // прогресс в процентах от target_value
 }
 
 type UserQuests struct {
 	UserID      int       `json:"user_id" db:"user_id"`
 	QuestID     int       `json:"quest_id" db:"quest_id"`
-	Status      string    `json:"status" db:"status"` // not_started, "active", "completed", TODO: failed
+	Status      string    `json:"status" db:"status"` // "purchased", "started", "completed", "failed"
 	StartedAt   time.Time `json:"started_at" db:"started_at"`
 	CompletedAt time.Time `json:"completed_at" db:"completed_at"`
 	ExpiresAt   time.Time `json:"expires_at" db:"expires_at"`
@@ -84,3 +145,35 @@ func NewSearchQuestsResponse(quests []Quest, resp RecommendationService_SearchQu
 	}
 	return result
 }
+
+// QuestAttempt - завершившаяся (проваленная) попытка прохождения квеста, сохраненная перед retry
+type QuestAttempt struct {
+	ID            int              `json:"id" db:"id"`
+	UserID        int              `json:"user_id" db:"user_id"`
+	QuestID       int              `json:"quest_id" db:"quest_id"`
+	Attempt       int              `json:"attempt" db:"attempt"`
+	QuestVersion  int              `json:"quest_version" db:"quest_version"`
+	Status        string           `json:"status" db:"status"`
+	XpGained      *int             `json:"xp_gained" db:"xp_gained"`
+	CoinGained    *int             `json:"coin_gained" db:"coin_gained"`
+	StartedAt     *time.Time       `json:"started_at" db:"started_at"`
+	CompletedAt   *time.Time       `json:"completed_at" db:"completed_at"`
+	ExpiresAt     *time.Time       `json:"expires_at" db:"expires_at"`
+	TasksSnapshot *json.RawMessage `json:"tasks_snapshot" db:"tasks_snapshot"`
+	ArchivedAt    time.Time        `json:"archived_at" db:"archived_at"`
+}
+
+// TaskVariant - альтернативный способ выполнить шаг квеста (например "пробежать 3 км" или "проплыть 30 минут")
+type TaskVariant struct {
+	ID             int       `json:"id" db:"id"`
+	TaskID         int       `json:"task_id" db:"task_id"`
+	Title          string    `json:"title" db:"title"`
+	Description    string    `json:"description" db:"description"`
+	BaseXpReward   int       `json:"base_xp_reward" db:"base_xp_reward"`
+	BaseCoinReward int       `json:"base_coin_reward" db:"base_coin_reward"`
+	CreatedAt      time.Time `json:"created_at" db:"created_at"`
+}
+
+type ChooseTaskVariantRequest struct {
+	VariantID int `json:"variant_id" binding:"required"`
+}
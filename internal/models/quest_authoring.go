@@ -0,0 +1,241 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+)
+
+// ErrInvalidQuest - определение квеста (ручное создание / изменение) не прошло проверку
+var ErrInvalidQuest = errors.New("invalid quest")
+
+// Допустимые значения полей квеста и задач при ручном создании
+var (
+	QuestCategories = []string{"health", "mental_health", "intelligence", "charisma", "willpower"}
+	QuestRarities   = []string{"free", "common", "rare", "epic", "legendary"}
+	TaskTypes       = []string{"daily", "weekly", "special", "user_generated"}
+)
+
+const (
+	MinQuestDifficulty = 1
+	MaxQuestDifficulty = 10
+
+	// Награда за квест - сумма наград задач * QuestRewardMultiplier, цена - reward_coin * QuestPriceMultiplier
+	QuestRewardMultiplier = 1.5
+	QuestPriceMultiplier  = 1.5
+
+	// Максимальная награда задачи (и ее вариантов) за единицу difficulty
+	MaxTaskXPPerDifficulty   = 50
+	MaxTaskCoinPerDifficulty = 25
+)
+
+// SaveQuestRequest - квест с задачами для ручного создания / изменения (тот же формат, что у AIQuestResponse)
+type SaveQuestRequest struct {
+	Quest *Quest `json:"quest" binding:"required"`
+	Tasks []Task `json:"tasks" binding:"required,min=1"`
+}
+
+// ApplyQuestFormulas заполняет не заданные (нулевые) вычисляемые поля квеста по его задачам:
+// tasks_count, difficulty (среднее difficulty задач), reward_xp, reward_coin и price (0 для rarity free).
+// Незаполненный task_order задач проставляется по их порядку в массиве.
+func ApplyQuestFormulas(quest *Quest, tasks []Task) {
+	for i := range tasks {
+		if tasks[i].TaskOrder == 0 {
+			tasks[i].TaskOrder = i + 1
+		}
+	}
+
+	if quest.TasksCount == 0 {
+		quest.TasksCount = len(tasks)
+	}
+
+	difficulty, xp, coins := questFormulas(tasks)
+	if quest.Difficulty == 0 {
+		quest.Difficulty = difficulty
+	}
+	if quest.RewardXP == 0 {
+		quest.RewardXP = xp
+	}
+	if quest.RewardCoin == 0 {
+		quest.RewardCoin = coins
+	}
+	if quest.Price == 0 && quest.Rarity != "free" {
+		quest.Price = questPrice(quest.RewardCoin)
+	}
+}
+
+// ResetQuestFormulas обнуляет вычисляемые поля квеста, чтобы ApplyQuestFormulas пересчитал их заново
+func ResetQuestFormulas(quest *Quest) {
+	quest.TasksCount = 0
+	quest.Difficulty = 0
+	quest.RewardXP = 0
+	quest.RewardCoin = 0
+	quest.Price = 0
+}
+
+// ValidateQuestDefinition проверяет квест и его задачи перед сохранением.
+// Возвращает ErrInvalidQuest со списком всех найденных проблем.
+func ValidateQuestDefinition(quest *Quest, tasks []Task) error {
+	var problems []string
+	add := func(format string, args ...any) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	if strings.TrimSpace(quest.Title) == "" {
+		add("title is required")
+	}
+	if !slices.Contains(QuestCategories, quest.Category) {
+		add("category must be one of %s", strings.Join(QuestCategories, ", "))
+	}
+	if !slices.Contains(QuestRarities, quest.Rarity) {
+		add("rarity must be one of %s", strings.Join(QuestRarities, ", "))
+	}
+	if quest.Visibility != "" && !IsValidQuestVisibility(quest.Visibility) {
+		add("visibility must be one of private, friends, public")
+	}
+	if quest.TimeLimitHours < 0 {
+		add("time_limit_hours can't be negative")
+	}
+	if quest.WindowPolicy != "" && !IsValidSchedulePolicy(quest.WindowPolicy) {
+		add("unknown window_policy %q", quest.WindowPolicy)
+	}
+	if quest.DeadlinePolicy != "" && !IsValidSchedulePolicy(quest.DeadlinePolicy) {
+		add("unknown deadline_policy %q", quest.DeadlinePolicy)
+	}
+	if quest.ReducedRewardPercent < 0 || quest.ReducedRewardPercent > 100 {
+		add("reduced_reward_percent must be between 0 and 100")
+	}
+	if _, err := ParseQuestConditions(quest.ConditionsJson); err != nil {
+		add("%v", err)
+	}
+	if _, err := ParseBonusEffects(quest.BonusJson); err != nil {
+		add("%v", err)
+	}
+
+	if len(tasks) == 0 {
+		add("quest must have at least one task")
+	}
+
+	orders := make([]int, 0, len(tasks))
+	for i, t := range tasks {
+		prefix := fmt.Sprintf("tasks[%d]", i)
+
+		if strings.TrimSpace(t.Title) == "" {
+			add("%s.title is required", prefix)
+		}
+		if !slices.Contains(QuestCategories, t.Category) {
+			add("%s.category must be one of %s", prefix, strings.Join(QuestCategories, ", "))
+		}
+		if !slices.Contains(QuestRarities, t.Rarity) {
+			add("%s.rarity must be one of %s", prefix, strings.Join(QuestRarities, ", "))
+		}
+		if t.Difficulty < MinQuestDifficulty || t.Difficulty > MaxQuestDifficulty {
+			add("%s.difficulty must be between %d and %d", prefix, MinQuestDifficulty, MaxQuestDifficulty)
+		}
+		checkTaskRewards(add, prefix, quest.Rarity, t.Difficulty, t.BaseXpReward, t.BaseCoinReward)
+		for j, v := range t.Variants {
+			checkTaskRewards(add, fmt.Sprintf("%s.variants[%d]", prefix, j), quest.Rarity, t.Difficulty, v.BaseXpReward, v.BaseCoinReward)
+		}
+		if t.Type != "" && !slices.Contains(TaskTypes, t.Type) {
+			add("%s.type must be one of %s", prefix, strings.Join(TaskTypes, ", "))
+		}
+		if t.CooldownHours < 0 {
+			add("%s.cooldown_hours can't be negative", prefix)
+		}
+		if t.RequiredOccurrences < 0 {
+			add("%s.required_occurrences can't be negative", prefix)
+		}
+		if (t.Type == "" || t.Type == "special") && t.RequiredOccurrences > 1 {
+			add("%s.required_occurrences is only allowed for daily / weekly tasks", prefix)
+		}
+		if t.ProofType != "" && !IsValidProofType(t.ProofType) {
+			add("%s: unknown proof_type %q", prefix, t.ProofType)
+		}
+		if t.TargetValue != nil && *t.TargetValue <= 0 {
+			add("%s.target_value must be positive", prefix)
+		}
+		if t.Unit != nil && t.TargetValue == nil {
+			add("%s.unit requires target_value", prefix)
+		}
+
+		orders = append(orders, t.TaskOrder)
+	}
+
+	// task_order - перестановка 1..n
+	slices.Sort(orders)
+	for i, order := range orders {
+		if order != i+1 {
+			add("task_order must be unique and go from 1 to %d", len(tasks))
+			break
+		}
+	}
+
+	if len(tasks) > 0 {
+		difficulty, xp, coins := questFormulas(tasks)
+		if quest.TasksCount != len(tasks) {
+			add("tasks_count must be %d (number of tasks)", len(tasks))
+		}
+		if quest.Difficulty != difficulty {
+			add("difficulty must be %d (average task difficulty)", difficulty)
+		}
+		if quest.RewardXP != xp {
+			add("reward_xp must be %d (sum of base_xp_reward * %.1f)", xp, QuestRewardMultiplier)
+		}
+		if quest.RewardCoin != coins {
+			add("reward_coin must be %d (sum of base_coin_reward * %.1f)", coins, QuestRewardMultiplier)
+		}
+		if quest.Rarity == "free" && quest.Price != 0 {
+			add("price of a free quest must be 0")
+		}
+		if quest.Rarity != "free" && quest.Price != questPrice(quest.RewardCoin) {
+			add("price must be %d (reward_coin * %.1f)", questPrice(quest.RewardCoin), QuestPriceMultiplier)
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("%w: %s", ErrInvalidQuest, strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// checkTaskRewards проверяет награду задачи или ее варианта: не отрицательная, не больше
+// Max*PerDifficulty * difficulty задачи, а в бесплатном квесте задачи не приносят монет
+// (иначе его можно проходить ради монет, ничего не платя)
+func checkTaskRewards(add func(string, ...any), prefix, questRarity string, difficulty, xp, coins int) {
+	if xp < 0 || coins < 0 {
+		add("%s rewards can't be negative", prefix)
+	}
+	if difficulty > 0 && xp > MaxTaskXPPerDifficulty*difficulty {
+		add("%s.base_xp_reward must be at most %d (%d per difficulty)", prefix, MaxTaskXPPerDifficulty*difficulty, MaxTaskXPPerDifficulty)
+	}
+	if questRarity == "free" && coins != 0 {
+		add("%s.base_coin_reward must be 0 in a free quest", prefix)
+	} else if difficulty > 0 && coins > MaxTaskCoinPerDifficulty*difficulty {
+		add("%s.base_coin_reward must be at most %d (%d per difficulty)", prefix, MaxTaskCoinPerDifficulty*difficulty, MaxTaskCoinPerDifficulty)
+	}
+}
+
+// questFormulas считает difficulty, reward_xp и reward_coin квеста по его задачам
+func questFormulas(tasks []Task) (difficulty, xp, coins int) {
+	if len(tasks) == 0 {
+		return 0, 0, 0
+	}
+
+	var sumDifficulty, sumXP, sumCoins int
+	for _, t := range tasks {
+		sumDifficulty += t.Difficulty
+		sumXP += t.BaseXpReward
+		sumCoins += t.BaseCoinReward
+	}
+
+	difficulty = int(math.Round(float64(sumDifficulty) / float64(len(tasks))))
+	xp = int(math.Round(float64(sumXP) * QuestRewardMultiplier))
+	coins = int(math.Round(float64(sumCoins) * QuestRewardMultiplier))
+	return difficulty, xp, coins
+}
+
+func questPrice(rewardCoin int) int {
+	return int(math.Round(float64(rewardCoin) * QuestPriceMultiplier))
+}
@@ -0,0 +1,229 @@
+package models
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func testQuestTasks() []Task {
+	return []Task{
+		{Title: "Пробежка", Category: "health", Rarity: "common", Difficulty: 2, BaseXpReward: 20, BaseCoinReward: 10},
+		{Title: "Заплыв", Category: "health", Rarity: "common", Difficulty: 4, BaseXpReward: 40, BaseCoinReward: 20},
+	}
+}
+
+func TestApplyQuestFormulas(t *testing.T) {
+	t.Run("fills omitted fields", func(t *testing.T) {
+		quest := &Quest{Title: "Марафон", Category: "health", Rarity: "common"}
+		tasks := testQuestTasks()
+
+		ApplyQuestFormulas(quest, tasks)
+
+		if quest.TasksCount != 2 || quest.Difficulty != 3 || quest.RewardXP != 90 || quest.RewardCoin != 45 {
+			t.Errorf("got tasks_count=%d difficulty=%d reward_xp=%d reward_coin=%d, want 2, 3, 90, 45",
+				quest.TasksCount, quest.Difficulty, quest.RewardXP, quest.RewardCoin)
+		}
+		// 45 * 1.5 = 67.5 округляется вверх
+		if quest.Price != 68 {
+			t.Errorf("price = %d, want 68", quest.Price)
+		}
+		for i, task := range tasks {
+			if task.TaskOrder != i+1 {
+				t.Errorf("tasks[%d].task_order = %d, want %d", i, task.TaskOrder, i+1)
+			}
+		}
+	})
+
+	t.Run("keeps given fields", func(t *testing.T) {
+		quest := &Quest{Rarity: "common", Difficulty: 7, RewardXP: 1, RewardCoin: 2, Price: 3}
+		tasks := testQuestTasks()
+		tasks[0].TaskOrder, tasks[1].TaskOrder = 2, 1
+
+		ApplyQuestFormulas(quest, tasks)
+
+		if quest.Difficulty != 7 || quest.RewardXP != 1 || quest.RewardCoin != 2 || quest.Price != 3 {
+			t.Errorf("given fields were overwritten: %+v", quest)
+		}
+		if tasks[0].TaskOrder != 2 || tasks[1].TaskOrder != 1 {
+			t.Errorf("given task_order was overwritten: %d, %d", tasks[0].TaskOrder, tasks[1].TaskOrder)
+		}
+	})
+
+	t.Run("free quest costs nothing", func(t *testing.T) {
+		quest := &Quest{Rarity: "free"}
+		ApplyQuestFormulas(quest, testQuestTasks())
+
+		if quest.Price != 0 {
+			t.Errorf("price = %d, want 0", quest.Price)
+		}
+	})
+}
+
+func TestValidateQuestDefinition(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(quest *Quest, tasks []Task) []Task
+		want   string // подстрока ошибки, "" - квест корректен
+	}{
+		{
+			name: "valid",
+		},
+		{
+			name: "free quest without coin rewards",
+			modify: func(quest *Quest, tasks []Task) []Task {
+				quest.Rarity = "free"
+				tasks[0].BaseCoinReward, tasks[1].BaseCoinReward = 0, 0
+				return tasks
+			},
+		},
+		{
+			name: "rewards at the cap",
+			modify: func(quest *Quest, tasks []Task) []Task {
+				tasks[0].BaseXpReward = 2 * MaxTaskXPPerDifficulty
+				tasks[0].BaseCoinReward = 2 * MaxTaskCoinPerDifficulty
+				return tasks
+			},
+		},
+		{
+			name: "missing title",
+			modify: func(quest *Quest, tasks []Task) []Task {
+				quest.Title = " "
+				return tasks
+			},
+			want: "title is required",
+		},
+		{
+			name: "unknown category",
+			modify: func(quest *Quest, tasks []Task) []Task {
+				quest.Category = "creativity"
+				return tasks
+			},
+			want: "category must be one of",
+		},
+		{
+			name: "no tasks",
+			modify: func(quest *Quest, tasks []Task) []Task {
+				return nil
+			},
+			want: "quest must have at least one task",
+		},
+		{
+			name: "task difficulty out of range",
+			modify: func(quest *Quest, tasks []Task) []Task {
+				tasks[0].Difficulty = MaxQuestDifficulty + 1
+				return tasks
+			},
+			want: "tasks[0].difficulty must be between 1 and 10",
+		},
+		{
+			name: "negative reward",
+			modify: func(quest *Quest, tasks []Task) []Task {
+				tasks[1].BaseXpReward = -1
+				return tasks
+			},
+			want: "tasks[1] rewards can't be negative",
+		},
+		{
+			name: "xp reward over the cap",
+			modify: func(quest *Quest, tasks []Task) []Task {
+				tasks[0].BaseXpReward = 2*MaxTaskXPPerDifficulty + 1
+				return tasks
+			},
+			want: "tasks[0].base_xp_reward must be at most 100",
+		},
+		{
+			name: "coin reward over the cap",
+			modify: func(quest *Quest, tasks []Task) []Task {
+				tasks[1].BaseCoinReward = 4*MaxTaskCoinPerDifficulty + 1
+				return tasks
+			},
+			want: "tasks[1].base_coin_reward must be at most 100",
+		},
+		{
+			name: "variant reward over the cap",
+			modify: func(quest *Quest, tasks []Task) []Task {
+				tasks[0].Variants = []TaskVariant{{Title: "Заплыв", BaseXpReward: 1000}}
+				return tasks
+			},
+			want: "tasks[0].variants[0].base_xp_reward must be at most 100",
+		},
+		{
+			name: "coins in a free quest",
+			modify: func(quest *Quest, tasks []Task) []Task {
+				quest.Rarity = "free"
+				return tasks
+			},
+			want: "tasks[0].base_coin_reward must be 0 in a free quest",
+		},
+		{
+			name: "reward_xp doesn't match the formula",
+			modify: func(quest *Quest, tasks []Task) []Task {
+				quest.RewardXP = 1000
+				return tasks
+			},
+			want: "reward_xp must be 90",
+		},
+		{
+			name: "price doesn't match the formula",
+			modify: func(quest *Quest, tasks []Task) []Task {
+				quest.Price = 1
+				return tasks
+			},
+			want: "price must be 68",
+		},
+		{
+			name: "paid free quest",
+			modify: func(quest *Quest, tasks []Task) []Task {
+				quest.Rarity = "free"
+				quest.Price = 10
+				tasks[0].BaseCoinReward, tasks[1].BaseCoinReward = 0, 0
+				return tasks
+			},
+			want: "price of a free quest must be 0",
+		},
+		{
+			name: "duplicate task_order",
+			modify: func(quest *Quest, tasks []Task) []Task {
+				tasks[0].TaskOrder, tasks[1].TaskOrder = 1, 1
+				return tasks
+			},
+			want: "task_order must be unique and go from 1 to 2",
+		},
+		{
+			name: "required_occurrences of a special task",
+			modify: func(quest *Quest, tasks []Task) []Task {
+				tasks[0].RequiredOccurrences = 3
+				return tasks
+			},
+			want: "tasks[0].required_occurrences is only allowed for daily / weekly tasks",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			quest := &Quest{Title: "Марафон", Category: "health", Rarity: "common"}
+			tasks := testQuestTasks()
+			if tt.modify != nil {
+				tasks = tt.modify(quest, tasks)
+			}
+			// Формулы заполняют только то, что не задано в тесте
+			ApplyQuestFormulas(quest, tasks)
+
+			err := ValidateQuestDefinition(quest, tasks)
+			if tt.want == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+
+			if !errors.Is(err, ErrInvalidQuest) {
+				t.Fatalf("error = %v, want ErrInvalidQuest", err)
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error = %q, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+}
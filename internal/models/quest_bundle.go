@@ -0,0 +1,333 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// QuestBundleFormatVersion - версия формата бандла квестов. Меняется при несовместимых изменениях формата.
+const QuestBundleFormatVersion = 1
+
+// Форматы файла бандла
+const (
+	BundleFormatJSON = "json"
+	BundleFormatYAML = "yaml"
+)
+
+var ErrInvalidBundle = errors.New("invalid quest bundle")
+
+// QuestBundle - переносимое описание квестов для импорта / экспорта (JSON или YAML).
+// Квесты сопоставляются с БД по external_key, поэтому повторный импорт того же бандла ничего не меняет.
+//
+// Пример (YAML):
+//
+//	format_version: 1
+//	quests:
+//	  - external_key: morning-marathon
+//	    title: Утренний марафон
+//	    category: health
+//	    rarity: common
+//	    time_limit_hours: 168
+//	    conditions: {min_level: 2}
+//	    bonuses: [{type: streak_freeze, count: 1}]
+//	    tasks:
+//	      - {title: Зарядка, category: health, rarity: common, difficulty: 1, base_xp_reward: 20, base_coin_reward: 10, task_order: 1}
+type QuestBundle struct {
+	FormatVersion int           `json:"format_version" yaml:"format_version"`
+	Quests        []BundleQuest `json:"quests" yaml:"quests"`
+}
+
+// BundleQuest - квест в бандле. Вычисляемые поля (tasks_count, difficulty, награды, price) можно не указывать.
+type BundleQuest struct {
+	ExternalKey          string       `json:"external_key" yaml:"external_key"`
+	Title                string       `json:"title" yaml:"title"`
+	Description          string       `json:"description" yaml:"description"`
+	Category             string       `json:"category" yaml:"category"`
+	Rarity               string       `json:"rarity" yaml:"rarity"`
+	Difficulty           int          `json:"difficulty,omitempty" yaml:"difficulty,omitempty"`
+	Price                int          `json:"price,omitempty" yaml:"price,omitempty"`
+	TasksCount           int          `json:"tasks_count,omitempty" yaml:"tasks_count,omitempty"`
+	RewardXP             int          `json:"reward_xp,omitempty" yaml:"reward_xp,omitempty"`
+	RewardCoin           int          `json:"reward_coin,omitempty" yaml:"reward_coin,omitempty"`
+	TimeLimitHours       int          `json:"time_limit_hours" yaml:"time_limit_hours"`
+	IsSequential         bool         `json:"is_sequential" yaml:"is_sequential"`
+	RequiresConfirmation bool         `json:"requires_confirmation" yaml:"requires_confirmation"`
+	WindowPolicy         string       `json:"window_policy,omitempty" yaml:"window_policy,omitempty"`
+	DeadlinePolicy       string       `json:"deadline_policy,omitempty" yaml:"deadline_policy,omitempty"`
+	ReducedRewardPercent int          `json:"reduced_reward_percent,omitempty" yaml:"reduced_reward_percent,omitempty"`
+	Conditions           any          `json:"conditions,omitempty" yaml:"conditions,omitempty"` // см. QuestConditions
+	Bonuses              any          `json:"bonuses,omitempty" yaml:"bonuses,omitempty"`       // см. BonusEffect
+	Tasks                []BundleTask `json:"tasks" yaml:"tasks"`
+}
+
+type BundleTask struct {
+	Title               string              `json:"title" yaml:"title"`
+	Description         string              `json:"description" yaml:"description"`
+	Difficulty          int                 `json:"difficulty" yaml:"difficulty"`
+	Rarity              string              `json:"rarity" yaml:"rarity"`
+	Category            string              `json:"category" yaml:"category"`
+	BaseXpReward        int                 `json:"base_xp_reward" yaml:"base_xp_reward"`
+	BaseCoinReward      int                 `json:"base_coin_reward" yaml:"base_coin_reward"`
+	TaskOrder           int                 `json:"task_order" yaml:"task_order"`
+	Type                string              `json:"type,omitempty" yaml:"type,omitempty"`
+	CooldownHours       int                 `json:"cooldown_hours,omitempty" yaml:"cooldown_hours,omitempty"`
+	RequiredOccurrences int                 `json:"required_occurrences,omitempty" yaml:"required_occurrences,omitempty"`
+	ProofType           string              `json:"proof_type,omitempty" yaml:"proof_type,omitempty"`
+	TargetValue         *float64            `json:"target_value,omitempty" yaml:"target_value,omitempty"`
+	Unit                *string             `json:"unit,omitempty" yaml:"unit,omitempty"`
+	Variants            []BundleTaskVariant `json:"variants,omitempty" yaml:"variants,omitempty"`
+}
+
+type BundleTaskVariant struct {
+	Title          string `json:"title" yaml:"title"`
+	Description    string `json:"description" yaml:"description"`
+	BaseXpReward   int    `json:"base_xp_reward" yaml:"base_xp_reward"`
+	BaseCoinReward int    `json:"base_coin_reward" yaml:"base_coin_reward"`
+}
+
+// Результат импорта одного квеста бандла
+const (
+	BundleRowCreated   = "created"
+	BundleRowUpdated   = "updated" // опубликована новая версия квеста
+	BundleRowUnchanged = "unchanged"
+	BundleRowError     = "error"
+)
+
+type BundleRowResult struct {
+	Index       int    `json:"index"` // позиция квеста в бандле (с 0)
+	ExternalKey string `json:"external_key"`
+	Action      string `json:"action"`
+	QuestID     int    `json:"quest_id,omitempty"`
+	Version     int    `json:"version,omitempty"`
+	Error       string `json:"error,omitempty"`
+}
+
+type BundleImportResult struct {
+	Created   int               `json:"created"`
+	Updated   int               `json:"updated"`
+	Unchanged int               `json:"unchanged"`
+	Failed    int               `json:"failed"`
+	Rows      []BundleRowResult `json:"rows"`
+}
+
+// ParseQuestBundle разбирает бандл в формате json или yaml и проверяет версию формата
+func ParseQuestBundle(data []byte, format string) (*QuestBundle, error) {
+	var bundle QuestBundle
+	var err error
+	switch format {
+	case BundleFormatYAML:
+		err = yaml.Unmarshal(data, &bundle)
+	case BundleFormatJSON, "":
+		err = json.Unmarshal(data, &bundle)
+	default:
+		return nil, fmt.Errorf("%w: unknown format %q", ErrInvalidBundle, format)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidBundle, err)
+	}
+
+	if bundle.FormatVersion != QuestBundleFormatVersion {
+		return nil, fmt.Errorf("%w: unsupported format_version %d (expected %d)",
+			ErrInvalidBundle, bundle.FormatVersion, QuestBundleFormatVersion)
+	}
+
+	return &bundle, nil
+}
+
+// MarshalQuestBundle сериализует бандл в формате json или yaml
+func MarshalQuestBundle(bundle *QuestBundle, format string) ([]byte, error) {
+	switch format {
+	case BundleFormatYAML:
+		return yaml.Marshal(bundle)
+	case BundleFormatJSON, "":
+		return json.MarshalIndent(bundle, "", "  ")
+	}
+	return nil, fmt.Errorf("%w: unknown format %q", ErrInvalidBundle, format)
+}
+
+// ToQuest переводит квест бандла в модель квеста с задачами
+func (b *BundleQuest) ToQuest() (*Quest, []Task, error) {
+	conditions, err := rawJSON(b.Conditions)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: conditions: %v", ErrInvalidQuest, err)
+	}
+	bonuses, err := rawJSON(b.Bonuses)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: bonuses: %v", ErrInvalidQuest, err)
+	}
+
+	externalKey := strings.TrimSpace(b.ExternalKey)
+	quest := &Quest{
+		ExternalKey:          &externalKey,
+		Title:                b.Title,
+		Description:          b.Description,
+		Category:             b.Category,
+		Rarity:               b.Rarity,
+		Difficulty:           b.Difficulty,
+		Price:                b.Price,
+		TasksCount:           b.TasksCount,
+		RewardXP:             b.RewardXP,
+		RewardCoin:           b.RewardCoin,
+		TimeLimitHours:       b.TimeLimitHours,
+		IsSequential:         b.IsSequential,
+		RequiresConfirmation: b.RequiresConfirmation,
+		WindowPolicy:         b.WindowPolicy,
+		DeadlinePolicy:       b.DeadlinePolicy,
+		ReducedRewardPercent: b.ReducedRewardPercent,
+		ConditionsJson:       conditions,
+		BonusJson:            bonuses,
+		Visibility:           QuestVisibilityPublic,
+	}
+
+	tasks := make([]Task, len(b.Tasks))
+	for i, t := range b.Tasks {
+		tasks[i] = Task{
+			Title:               t.Title,
+			Description:         t.Description,
+			Difficulty:          t.Difficulty,
+			Rarity:              t.Rarity,
+			Category:            t.Category,
+			BaseXpReward:        t.BaseXpReward,
+			BaseCoinReward:      t.BaseCoinReward,
+			TaskOrder:           t.TaskOrder,
+			Type:                t.Type,
+			CooldownHours:       t.CooldownHours,
+			RequiredOccurrences: t.RequiredOccurrences,
+			ProofType:           t.ProofType,
+			TargetValue:         t.TargetValue,
+			Unit:                t.Unit,
+		}
+		for _, v := range t.Variants {
+			tasks[i].Variants = append(tasks[i].Variants, TaskVariant{
+				Title:          v.Title,
+				Description:    v.Description,
+				BaseXpReward:   v.BaseXpReward,
+				BaseCoinReward: v.BaseCoinReward,
+			})
+		}
+	}
+
+	return quest, tasks, nil
+}
+
+// NewBundleQuest переводит квест с задачами в квест бандла. Значения по умолчанию заполняются явно,
+// поэтому результаты для квеста из БД и для того же квеста из бандла совпадают (см. SameBundleQuest).
+func NewBundleQuest(quest *Quest, tasks []Task) (BundleQuest, error) {
+	b := BundleQuest{
+		Title:                quest.Title,
+		Description:          quest.Description,
+		Category:             quest.Category,
+		Rarity:               quest.Rarity,
+		Difficulty:           quest.Difficulty,
+		Price:                quest.Price,
+		TasksCount:           quest.TasksCount,
+		RewardXP:             quest.RewardXP,
+		RewardCoin:           quest.RewardCoin,
+		TimeLimitHours:       quest.TimeLimitHours,
+		IsSequential:         quest.IsSequential,
+		RequiresConfirmation: quest.RequiresConfirmation,
+		WindowPolicy:         cmpOr(quest.WindowPolicy, SchedulePolicyAllow),
+		DeadlinePolicy:       cmpOr(quest.DeadlinePolicy, SchedulePolicyAllow),
+		ReducedRewardPercent: quest.ReducedRewardPercent,
+		Tasks:                make([]BundleTask, 0, len(tasks)),
+	}
+	if quest.ExternalKey != nil {
+		b.ExternalKey = *quest.ExternalKey
+	}
+	if b.ReducedRewardPercent == 0 {
+		b.ReducedRewardPercent = 50
+	}
+
+	var err error
+	if b.Conditions, err = decodeRawJSON(quest.ConditionsJson); err != nil {
+		return b, err
+	}
+	if b.Bonuses, err = decodeRawJSON(quest.BonusJson); err != nil {
+		return b, err
+	}
+
+	for _, t := range tasks {
+		bt := BundleTask{
+			Title:               t.Title,
+			Description:         t.Description,
+			Difficulty:          t.Difficulty,
+			Rarity:              t.Rarity,
+			Category:            t.Category,
+			BaseXpReward:        t.BaseXpReward,
+			BaseCoinReward:      t.BaseCoinReward,
+			TaskOrder:           t.TaskOrder,
+			Type:                cmpOr(t.Type, "special"),
+			CooldownHours:       t.CooldownHours,
+			RequiredOccurrences: max(t.RequiredOccurrences, 1),
+			ProofType:           cmpOr(t.ProofType, ProofTypeNone),
+			TargetValue:         t.TargetValue,
+			Unit:                t.Unit,
+		}
+		for _, v := range t.Variants {
+			bt.Variants = append(bt.Variants, BundleTaskVariant{
+				Title:          v.Title,
+				Description:    v.Description,
+				BaseXpReward:   v.BaseXpReward,
+				BaseCoinReward: v.BaseCoinReward,
+			})
+		}
+		b.Tasks = append(b.Tasks, bt)
+	}
+
+	slices.SortStableFunc(b.Tasks, func(x, y BundleTask) int { return x.TaskOrder - y.TaskOrder })
+
+	return b, nil
+}
+
+// SameBundleQuest - совпадают ли квесты бандла (оба получены через NewBundleQuest)
+func SameBundleQuest(a, b BundleQuest) bool {
+	aj, err := json.Marshal(a)
+	if err != nil {
+		return false
+	}
+	bj, err := json.Marshal(b)
+	if err != nil {
+		return false
+	}
+	return string(aj) == string(bj)
+}
+
+// rawJSON кодирует условия / бонусы бандла в JSON для conditions_json / bonus_json
+func rawJSON(v any) (*json.RawMessage, error) {
+	if v == nil {
+		return nil, nil
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	raw := json.RawMessage(data)
+	return &raw, nil
+}
+
+// decodeRawJSON декодирует conditions_json / bonus_json в обычные значения (map / slice) для бандла
+func decodeRawJSON(raw *json.RawMessage) (any, error) {
+	if raw == nil || len(*raw) == 0 || string(*raw) == "null" {
+		return nil, nil
+	}
+
+	var v any
+	if err := json.Unmarshal(*raw, &v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+func cmpOr(value, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+	return value
+}
@@ -0,0 +1,153 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// QuestConditions - условия доступности квеста, хранятся в quests.conditions_json.
+// Все заданные условия должны выполняться одновременно (AND), AnyOf задает альтернативы (OR).
+//
+// Пример:
+//
+//	{
+//		"min_level": 3,
+//		"min_attributes": {"willpower_level": 2},
+//		"completed_quests": [1, 4],
+//		"achievements": [7],
+//		"available_from": "2026-01-01T00:00:00Z",
+//		"available_until": "2026-02-01T00:00:00Z",
+//		"any_of": [{"min_attributes": {"health_level": 5}}, {"completed_quests": [10]}]
+//	}
+type QuestConditions struct {
+	MinLevel        int               `json:"min_level,omitempty"`
+	MinAttributes   map[string]int    `json:"min_attributes,omitempty"`
+	CompletedQuests []int             `json:"completed_quests,omitempty"`
+	Achievements    []int             `json:"achievements,omitempty"`
+	AvailableFrom   *time.Time        `json:"available_from,omitempty"`
+	AvailableUntil  *time.Time        `json:"available_until,omitempty"`
+	AnyOf           []QuestConditions `json:"any_of,omitempty"`
+}
+
+// ConditionsState - состояние пользователя, против которого проверяются условия
+type ConditionsState struct {
+	Level             int
+	Attributes        map[string]int
+	CompletedQuestIDs []int
+	AchievementIDs    []int
+	Now               time.Time
+}
+
+// AttributeLevels возвращает характеристики пользователя по именам колонок users
+func (u User) AttributeLevels() map[string]int {
+	return map[string]int{
+		"health_level":        u.HealthLevel,
+		"mental_health_level": u.MentalHealthLevel,
+		"intelligence_level":  u.IntelligenceLevel,
+		"charisma_level":      u.CharismaLevel,
+		"willpower_level":     u.WillpowerLevel,
+	}
+}
+
+// ParseQuestConditions разбирает conditions_json. Пустое значение означает отсутствие условий.
+func ParseQuestConditions(raw *json.RawMessage) (*QuestConditions, error) {
+	if raw == nil || len(*raw) == 0 || string(*raw) == "null" {
+		return nil, nil
+	}
+
+	var conditions QuestConditions
+	if err := json.Unmarshal(*raw, &conditions); err != nil {
+		return nil, fmt.Errorf("invalid conditions_json: %w", err)
+	}
+
+	if err := conditions.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &conditions, nil
+}
+
+// Validate проверяет, что условия ссылаются только на известные характеристики
+func (c *QuestConditions) Validate() error {
+	known := User{}.AttributeLevels()
+	for attr := range c.MinAttributes {
+		if _, ok := known[attr]; !ok {
+			return fmt.Errorf("invalid conditions_json: unknown attribute %q", attr)
+		}
+	}
+
+	for i := range c.AnyOf {
+		if err := c.AnyOf[i].Validate(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Evaluate возвращает причины, по которым квест недоступен. Пустой результат - условия выполнены.
+func (c *QuestConditions) Evaluate(state ConditionsState) []string {
+	if c == nil {
+		return nil
+	}
+
+	var reasons []string
+
+	if c.MinLevel > 0 && state.Level < c.MinLevel {
+		reasons = append(reasons, fmt.Sprintf("requires level %d (current: %d)", c.MinLevel, state.Level))
+	}
+
+	// сортируем ключи, чтобы причины возвращались в стабильном порядке
+	attrs := make([]string, 0, len(c.MinAttributes))
+	for attr := range c.MinAttributes {
+		attrs = append(attrs, attr)
+	}
+	slices.Sort(attrs)
+	for _, attr := range attrs {
+		required := c.MinAttributes[attr]
+		if current := state.Attributes[attr]; current < required {
+			reasons = append(reasons, fmt.Sprintf("requires %s %d (current: %d)", attr, required, current))
+		}
+	}
+
+	for _, questID := range c.CompletedQuests {
+		if !slices.Contains(state.CompletedQuestIDs, questID) {
+			reasons = append(reasons, fmt.Sprintf("requires completed quest %d", questID))
+		}
+	}
+
+	for _, achievementID := range c.Achievements {
+		if !slices.Contains(state.AchievementIDs, achievementID) {
+			reasons = append(reasons, fmt.Sprintf("requires achievement %d", achievementID))
+		}
+	}
+
+	if c.AvailableFrom != nil && state.Now.Before(*c.AvailableFrom) {
+		reasons = append(reasons, fmt.Sprintf("available from %s", c.AvailableFrom.Format(time.RFC3339)))
+	}
+
+	if c.AvailableUntil != nil && !state.Now.Before(*c.AvailableUntil) {
+		reasons = append(reasons, fmt.Sprintf("was available until %s", c.AvailableUntil.Format(time.RFC3339)))
+	}
+
+	if len(c.AnyOf) > 0 {
+		var alternatives []string
+		satisfied := false
+		for i := range c.AnyOf {
+			altReasons := c.AnyOf[i].Evaluate(state)
+			if len(altReasons) == 0 {
+				satisfied = true
+				break
+			}
+			alternatives = append(alternatives, altReasons...)
+		}
+
+		if !satisfied {
+			reasons = append(reasons, fmt.Sprintf("none of the alternatives is met: %v", alternatives))
+		}
+	}
+
+	return reasons
+}
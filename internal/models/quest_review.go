@@ -0,0 +1,54 @@
+package models
+
+import (
+	"cmp"
+	"slices"
+	"time"
+)
+
+// QuestSortRating - сортировка квестов по средней оценке (сначала лучшие, без оценок - в конце)
+const QuestSortRating = "rating"
+
+// QuestReview - оценка (1-5) и отзыв пользователя, завершившего квест
+type QuestReview struct {
+	ID           int       `json:"id" db:"id"`
+	UserID       int       `json:"user_id" db:"user_id"`
+	Username     string    `json:"username,omitempty" db:"username"`
+	QuestID      int       `json:"quest_id" db:"quest_id"`
+	QuestVersion int       `json:"quest_version" db:"quest_version"`
+	Rating       int       `json:"rating" db:"rating"`
+	Review       *string   `json:"review" db:"review"`
+	CreatedAt    time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at" db:"updated_at"`
+}
+
+type RateQuestRequest struct {
+	Rating int     `json:"rating" binding:"required,min=1,max=5"`
+	Review *string `json:"review"`
+}
+
+// CompareByRating сравнивает квесты для сортировки по средней оценке: выше оценка, затем больше оценок
+func CompareByRating(a, b *Quest) int {
+	if a.AverageRating == nil || b.AverageRating == nil {
+		// квесты без оценок - в конце
+		return cmp.Compare(boolToInt(a.AverageRating == nil), boolToInt(b.AverageRating == nil))
+	}
+	if c := cmp.Compare(*b.AverageRating, *a.AverageRating); c != 0 {
+		return c
+	}
+	return cmp.Compare(b.RatingCount, a.RatingCount)
+}
+
+// SortSearchResultsByRating сортирует результаты поиска по средней оценке квеста
+func SortSearchResultsByRating(results SearchQuestsResponse) {
+	slices.SortStableFunc(results, func(a, b QuestWithSimilarityScore) int {
+		return CompareByRating(&a.Quest, &b.Quest)
+	})
+}
+
+func boolToInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
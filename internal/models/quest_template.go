@@ -0,0 +1,403 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidTemplate       = errors.New("invalid quest template")
+	ErrInvalidTemplateParams = errors.New("invalid template parameters")
+)
+
+// Типы параметров шаблона квеста
+const (
+	TemplateParamInt      = "int"
+	TemplateParamNumber   = "number"
+	TemplateParamString   = "string"
+	TemplateParamCategory = "category" // одна из QuestCategories
+)
+
+// MaxTemplateTasks - сколько задач может получиться из шаблона (с учетом repeat_by)
+const MaxTemplateTasks = 100
+
+var (
+	// templatePlaceholder - подстановка значения параметра в текст шаблона: {distance}
+	templatePlaceholder = regexp.MustCompile(`\{(\w+)\}`)
+	templateParamName   = regexp.MustCompile(`^\w+$`)
+)
+
+// QuestTemplate - шаблон квеста с типизированными параметрами, например "Пробежать {distance} км за {days} дней".
+// Квест по шаблону создается подстановкой значений параметров (см. Instantiate).
+type QuestTemplate struct {
+	ID          int       `json:"id" db:"id"`
+	Title       string    `json:"title" db:"title"`
+	Description string    `json:"description" db:"description"`
+	CreatedBy   *int      `json:"created_by,omitempty" db:"created_by"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+
+	ParametersJson json.RawMessage `json:"-" db:"parameters_json"`
+	QuestJson      json.RawMessage `json:"-" db:"quest_json"`
+
+	Parameters []TemplateParam  `json:"parameters" db:"-"`
+	Quest      QuestTemplateDef `json:"quest" db:"-"`
+}
+
+// TemplateParam - параметр шаблона. Min / Max - для числовых параметров, Options - допустимые значения строки.
+// Параметр без Default обязателен.
+type TemplateParam struct {
+	Name    string   `json:"name" binding:"required"`
+	Label   string   `json:"label,omitempty"`
+	Type    string   `json:"type" binding:"required"`
+	Min     *float64 `json:"min,omitempty"`
+	Max     *float64 `json:"max,omitempty"`
+	Default any      `json:"default,omitempty"`
+	Options []string `json:"options,omitempty"`
+}
+
+// QuestTemplateDef - квест шаблона. В строковых полях можно использовать {параметр}.
+// Вычисляемые поля (difficulty квеста, награды, price, tasks_count) считаются по задачам (см. ApplyQuestFormulas).
+type QuestTemplateDef struct {
+	Title                string         `json:"title"`
+	Description          string         `json:"description"`
+	Category             string         `json:"category"`
+	Rarity               string         `json:"rarity"`
+	TimeLimitHours       int            `json:"time_limit_hours"`
+	TimeLimitBy          string         `json:"time_limit_by,omitempty"` // числовой параметр: лимит = time_limit_hours * значение
+	IsSequential         bool           `json:"is_sequential"`
+	RequiresConfirmation bool           `json:"requires_confirmation"`
+	Tasks                []TaskTemplate `json:"tasks"`
+}
+
+// TaskTemplate - задача шаблона
+type TaskTemplate struct {
+	Title          string `json:"title"`
+	Description    string `json:"description"`
+	Category       string `json:"category"`
+	Rarity         string `json:"rarity"`
+	Difficulty     int    `json:"difficulty"`
+	BaseXpReward   int    `json:"base_xp_reward"`
+	BaseCoinReward int    `json:"base_coin_reward"`
+	Type           string `json:"type,omitempty"`
+	CooldownHours  int    `json:"cooldown_hours,omitempty"`
+	ProofType      string `json:"proof_type,omitempty"`
+	Unit           string `json:"unit,omitempty"`
+
+	ScaleBy  string `json:"scale_by,omitempty"`  // числовой параметр: награды = base_*_reward * значение
+	RepeatBy string `json:"repeat_by,omitempty"` // int параметр: задача повторяется N раз, {n} - номер повтора
+	TargetBy string `json:"target_by,omitempty"` // числовой параметр: target_value задачи
+}
+
+type CreateQuestTemplateRequest struct {
+	Title       string           `json:"title" binding:"required"`
+	Description string           `json:"description"`
+	Parameters  []TemplateParam  `json:"parameters" binding:"dive"`
+	Quest       QuestTemplateDef `json:"quest"`
+}
+
+type InstantiateTemplateRequest struct {
+	Params map[string]any `json:"params"`
+}
+
+// Decode разбирает parameters_json и quest_json шаблона, прочитанного из БД
+func (t *QuestTemplate) Decode() error {
+	if err := json.Unmarshal(t.ParametersJson, &t.Parameters); err != nil {
+		return fmt.Errorf("template %d parameters: %w", t.ID, err)
+	}
+	if err := json.Unmarshal(t.QuestJson, &t.Quest); err != nil {
+		return fmt.Errorf("template %d quest: %w", t.ID, err)
+	}
+	return nil
+}
+
+// ValidateQuestTemplate проверяет параметры шаблона и ссылки на них. Если у всех параметров есть
+// значение для примера (default, min или первый вариант), проверяется и получающийся квест.
+func ValidateQuestTemplate(params []TemplateParam, def QuestTemplateDef) error {
+	var problems []string
+	add := func(format string, args ...any) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	byName := make(map[string]TemplateParam, len(params))
+	for i, p := range params {
+		prefix := fmt.Sprintf("parameters[%d]", i)
+		if !templateParamName.MatchString(p.Name) || p.Name == "n" {
+			add("%s.name must be a word other than n", prefix)
+		}
+		if _, ok := byName[p.Name]; ok {
+			add("%s: duplicate parameter %q", prefix, p.Name)
+		}
+		byName[p.Name] = p
+
+		switch p.Type {
+		case TemplateParamInt, TemplateParamNumber, TemplateParamString, TemplateParamCategory:
+		default:
+			add("%s.type must be one of int, number, string, category", prefix)
+			continue
+		}
+		if p.Min != nil && p.Max != nil && *p.Min > *p.Max {
+			add("%s: min is greater than max", prefix)
+		}
+		if p.Default != nil {
+			if _, err := p.resolve(p.Default); err != nil {
+				add("%s.default: %v", prefix, err)
+			}
+		}
+	}
+
+	numeric := func(field, name string, types ...string) {
+		if name == "" {
+			return
+		}
+		p, ok := byName[name]
+		if !ok {
+			add("%s refers to unknown parameter %q", field, name)
+			return
+		}
+		if !slices.Contains(types, p.Type) {
+			add("%s parameter %q must be of type %s", field, name, strings.Join(types, " or "))
+		}
+	}
+	placeholders := func(field, text string, allowN bool) {
+		for _, m := range templatePlaceholder.FindAllStringSubmatch(text, -1) {
+			if _, ok := byName[m[1]]; !ok && !(allowN && m[1] == "n") {
+				add("%s uses unknown parameter {%s}", field, m[1])
+			}
+		}
+	}
+
+	placeholders("quest.title", def.Title, false)
+	placeholders("quest.description", def.Description, false)
+	placeholders("quest.category", def.Category, false)
+	numeric("quest.time_limit_by", def.TimeLimitBy, TemplateParamInt, TemplateParamNumber)
+	if len(def.Tasks) == 0 {
+		add("quest must have at least one task")
+	}
+	for i, t := range def.Tasks {
+		prefix := fmt.Sprintf("quest.tasks[%d]", i)
+		allowN := t.RepeatBy != ""
+		placeholders(prefix+".title", t.Title, allowN)
+		placeholders(prefix+".description", t.Description, allowN)
+		placeholders(prefix+".category", t.Category, false)
+		numeric(prefix+".scale_by", t.ScaleBy, TemplateParamInt, TemplateParamNumber)
+		numeric(prefix+".repeat_by", t.RepeatBy, TemplateParamInt)
+		numeric(prefix+".target_by", t.TargetBy, TemplateParamInt, TemplateParamNumber)
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("%w: %s", ErrInvalidTemplate, strings.Join(problems, "; "))
+	}
+
+	// Пробный квест по значениям для примера
+	sample := make(map[string]any, len(params))
+	for _, p := range params {
+		v := p.sampleValue()
+		if v == nil {
+			return nil
+		}
+		sample[p.Name] = v
+	}
+	quest, tasks, err := QuestTemplate{Parameters: params, Quest: def}.Instantiate(sample)
+	if err == nil {
+		ApplyQuestFormulas(quest, tasks)
+		err = ValidateQuestDefinition(quest, tasks)
+	}
+	if err != nil {
+		return fmt.Errorf("%w: sample quest: %v", ErrInvalidTemplate, err)
+	}
+
+	return nil
+}
+
+// Instantiate создает квест и задачи по шаблону с переданными значениями параметров
+// (незаданные берутся из default). Вычисляемые поля квеста не заполняются.
+func (t QuestTemplate) Instantiate(values map[string]any) (*Quest, []Task, error) {
+	resolved := make(map[string]any, len(t.Parameters))
+	var problems []string
+	for _, p := range t.Parameters {
+		raw, ok := values[p.Name]
+		if !ok || raw == nil {
+			raw = p.Default
+		}
+		if raw == nil {
+			problems = append(problems, fmt.Sprintf("%s is required", p.Name))
+			continue
+		}
+		v, err := p.resolve(raw)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v", p.Name, err))
+			continue
+		}
+		resolved[p.Name] = v
+	}
+	for name := range values {
+		if !slices.ContainsFunc(t.Parameters, func(p TemplateParam) bool { return p.Name == name }) {
+			problems = append(problems, fmt.Sprintf("unknown parameter %s", name))
+		}
+	}
+	if len(problems) > 0 {
+		slices.Sort(problems)
+		return nil, nil, fmt.Errorf("%w: %s", ErrInvalidTemplateParams, strings.Join(problems, "; "))
+	}
+
+	subst := func(text string, n int) string {
+		return templatePlaceholder.ReplaceAllStringFunc(text, func(m string) string {
+			name := m[1 : len(m)-1]
+			if name == "n" && n > 0 {
+				return strconv.Itoa(n)
+			}
+			if v, ok := resolved[name]; ok {
+				return formatTemplateValue(v)
+			}
+			return m
+		})
+	}
+	number := func(name string) float64 {
+		if name == "" {
+			return 1
+		}
+		return toFloat(resolved[name])
+	}
+
+	def := t.Quest
+	quest := &Quest{
+		Title:                subst(def.Title, 0),
+		Description:          subst(def.Description, 0),
+		Category:             subst(def.Category, 0),
+		Rarity:               def.Rarity,
+		TimeLimitHours:       int(math.Round(float64(def.TimeLimitHours) * number(def.TimeLimitBy))),
+		IsSequential:         def.IsSequential,
+		RequiresConfirmation: def.RequiresConfirmation,
+	}
+
+	var tasks []Task
+	for _, tt := range def.Tasks {
+		repeat := 1
+		if tt.RepeatBy != "" {
+			repeat = int(number(tt.RepeatBy))
+		}
+		if repeat < 1 || len(tasks)+repeat > MaxTemplateTasks {
+			return nil, nil, fmt.Errorf("%w: quest must have from 1 to %d tasks", ErrInvalidTemplateParams, MaxTemplateTasks)
+		}
+
+		scale := number(tt.ScaleBy)
+		for i := 1; i <= repeat; i++ {
+			n := 0
+			if tt.RepeatBy != "" {
+				n = i
+			}
+			task := Task{
+				Title:          subst(tt.Title, n),
+				Description:    subst(tt.Description, n),
+				Category:       subst(tt.Category, 0),
+				Rarity:         tt.Rarity,
+				Difficulty:     tt.Difficulty,
+				BaseXpReward:   int(math.Round(float64(tt.BaseXpReward) * scale)),
+				BaseCoinReward: int(math.Round(float64(tt.BaseCoinReward) * scale)),
+				Type:           tt.Type,
+				CooldownHours:  tt.CooldownHours,
+				ProofType:      tt.ProofType,
+			}
+			if tt.TargetBy != "" {
+				target := number(tt.TargetBy)
+				task.TargetValue = &target
+				if tt.Unit != "" {
+					unit := tt.Unit
+					task.Unit = &unit
+				}
+			}
+			tasks = append(tasks, task)
+		}
+	}
+
+	return quest, tasks, nil
+}
+
+// resolve проверяет значение параметра и приводит его к типу параметра (int, float64 или string)
+func (p TemplateParam) resolve(raw any) (any, error) {
+	switch p.Type {
+	case TemplateParamInt, TemplateParamNumber:
+		f, ok := raw.(float64)
+		if !ok {
+			if i, isInt := raw.(int); isInt {
+				f, ok = float64(i), true
+			}
+		}
+		if !ok {
+			return nil, errors.New("must be a number")
+		}
+		if p.Min != nil && f < *p.Min {
+			return nil, fmt.Errorf("must be at least %s", formatTemplateValue(*p.Min))
+		}
+		if p.Max != nil && f > *p.Max {
+			return nil, fmt.Errorf("must be at most %s", formatTemplateValue(*p.Max))
+		}
+		if p.Type == TemplateParamInt {
+			if f != math.Trunc(f) {
+				return nil, errors.New("must be an integer")
+			}
+			return int(f), nil
+		}
+		return f, nil
+
+	case TemplateParamString, TemplateParamCategory:
+		s, ok := raw.(string)
+		if !ok {
+			return nil, errors.New("must be a string")
+		}
+		options := p.Options
+		if p.Type == TemplateParamCategory {
+			options = QuestCategories
+		}
+		if len(options) > 0 && !slices.Contains(options, s) {
+			return nil, fmt.Errorf("must be one of %s", strings.Join(options, ", "))
+		}
+		if strings.TrimSpace(s) == "" {
+			return nil, errors.New("can't be empty")
+		}
+		return s, nil
+	}
+
+	return nil, fmt.Errorf("unknown parameter type %q", p.Type)
+}
+
+// sampleValue - значение параметра для проверки шаблона (nil, если подходящего нет)
+func (p TemplateParam) sampleValue() any {
+	switch {
+	case p.Default != nil:
+		return p.Default
+	case p.Type == TemplateParamCategory:
+		return QuestCategories[0]
+	case len(p.Options) > 0:
+		return p.Options[0]
+	case p.Min != nil:
+		return *p.Min
+	}
+	return nil
+}
+
+func formatTemplateValue(v any) string {
+	switch v := v.(type) {
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func toFloat(v any) float64 {
+	switch v := v.(type) {
+	case int:
+		return float64(v)
+	case float64:
+		return v
+	}
+	return 0
+}
@@ -0,0 +1,148 @@
+package models
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func runningTemplate() QuestTemplate {
+	minDistance, maxDistance := 1.0, 42.0
+	return QuestTemplate{
+		Parameters: []TemplateParam{
+			{Name: "distance", Type: TemplateParamNumber, Min: &minDistance, Max: &maxDistance},
+			{Name: "days", Type: TemplateParamInt, Default: 3.0},
+			{Name: "category", Type: TemplateParamCategory, Default: "health"},
+		},
+		Quest: QuestTemplateDef{
+			Title:          "Пробежать {distance} км за {days} дней",
+			Category:       "{category}",
+			Rarity:         "common",
+			TimeLimitHours: 24,
+			TimeLimitBy:    "days",
+			Tasks: []TaskTemplate{
+				{
+					Title: "День {n}: пробежка", Category: "health", Rarity: "common", Difficulty: 2,
+					BaseXpReward: 10, BaseCoinReward: 5, RepeatBy: "days",
+				},
+				{
+					Title: "Итоговый забег", Category: "health", Rarity: "common", Difficulty: 3,
+					BaseXpReward: 10, BaseCoinReward: 4, ScaleBy: "distance", TargetBy: "distance", Unit: "km",
+				},
+			},
+		},
+	}
+}
+
+func TestQuestTemplateInstantiate(t *testing.T) {
+	quest, tasks, err := runningTemplate().Instantiate(map[string]any{"distance": 2.5})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if quest.Title != "Пробежать 2.5 км за 3 дней" {
+		t.Errorf("title = %q", quest.Title)
+	}
+	if quest.Category != "health" {
+		t.Errorf("category = %q, want default health", quest.Category)
+	}
+	if quest.TimeLimitHours != 72 {
+		t.Errorf("time_limit_hours = %d, want 24 * days = 72", quest.TimeLimitHours)
+	}
+
+	if len(tasks) != 4 {
+		t.Fatalf("got %d tasks, want 3 repeated + 1", len(tasks))
+	}
+	for i, title := range []string{"День 1: пробежка", "День 2: пробежка", "День 3: пробежка", "Итоговый забег"} {
+		if tasks[i].Title != title {
+			t.Errorf("tasks[%d].title = %q, want %q", i, tasks[i].Title, title)
+		}
+	}
+
+	last := tasks[3]
+	// 10 * 2.5 = 25, 4 * 2.5 = 10
+	if last.BaseXpReward != 25 || last.BaseCoinReward != 10 {
+		t.Errorf("scaled rewards = %d, %d, want 25, 10", last.BaseXpReward, last.BaseCoinReward)
+	}
+	if last.TargetValue == nil || *last.TargetValue != 2.5 || last.Unit == nil || *last.Unit != "km" {
+		t.Errorf("target = %v %v, want 2.5 km", last.TargetValue, last.Unit)
+	}
+	if tasks[0].TargetValue != nil {
+		t.Errorf("tasks[0] without target_by got target_value %v", *tasks[0].TargetValue)
+	}
+
+	// Вычисляемые поля не заполняются, но квест проходит проверку после формул
+	if quest.RewardXP != 0 || quest.Price != 0 {
+		t.Errorf("computed fields must stay empty, got reward_xp=%d price=%d", quest.RewardXP, quest.Price)
+	}
+	ApplyQuestFormulas(quest, tasks)
+	if err := ValidateQuestDefinition(quest, tasks); err != nil {
+		t.Errorf("instantiated quest is invalid: %v", err)
+	}
+}
+
+func TestQuestTemplateInstantiateInvalidParams(t *testing.T) {
+	tests := []struct {
+		name   string
+		values map[string]any
+		want   string
+	}{
+		{
+			name:   "missing required parameter",
+			values: map[string]any{},
+			want:   "distance is required",
+		},
+		{
+			name:   "below min",
+			values: map[string]any{"distance": 0.5},
+			want:   "distance: must be at least 1",
+		},
+		{
+			name:   "above max",
+			values: map[string]any{"distance": 50.0},
+			want:   "distance: must be at most 42",
+		},
+		{
+			name:   "not a number",
+			values: map[string]any{"distance": "far"},
+			want:   "distance: must be a number",
+		},
+		{
+			name:   "fractional int",
+			values: map[string]any{"distance": 5.0, "days": 1.5},
+			want:   "days: must be an integer",
+		},
+		{
+			name:   "unknown category",
+			values: map[string]any{"distance": 5.0, "category": "creativity"},
+			want:   "category: must be one of",
+		},
+		{
+			name:   "unknown parameter",
+			values: map[string]any{"distance": 5.0, "speed": 10.0},
+			want:   "unknown parameter speed",
+		},
+		{
+			name:   "too many repeats",
+			values: map[string]any{"distance": 5.0, "days": float64(MaxTemplateTasks)},
+			want:   "quest must have from 1 to 100 tasks",
+		},
+		{
+			name:   "no repeats",
+			values: map[string]any{"distance": 5.0, "days": 0.0},
+			want:   "quest must have from 1 to 100 tasks",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := runningTemplate().Instantiate(tt.values)
+			if !errors.Is(err, ErrInvalidTemplateParams) {
+				t.Fatalf("error = %v, want ErrInvalidTemplateParams", err)
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error = %q, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+}
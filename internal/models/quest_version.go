@@ -0,0 +1,85 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// QuestVersion - опубликованная версия квеста и ее отличия от предыдущей
+type QuestVersion struct {
+	Version   int           `json:"version"`
+	CreatedAt time.Time     `json:"created_at"`
+	Quest     Quest         `json:"quest"`
+	Changes   []QuestChange `json:"changes,omitempty"`
+}
+
+// QuestChange - изменившееся поле квеста или задачи (tasks[i].field; для добавленной / удаленной задачи - tasks[i])
+type QuestChange struct {
+	Field string `json:"field"`
+	Old   any    `json:"old,omitempty"`
+	New   any    `json:"new,omitempty"`
+}
+
+// DiffQuests сравнивает две версии квеста. Задачи сопоставляются по позиции (task_order).
+func DiffQuests(prev, cur *Quest) []QuestChange {
+	changes := []QuestChange{}
+	add := func(field string, old, new any) {
+		if !reflect.DeepEqual(old, new) {
+			changes = append(changes, QuestChange{Field: field, Old: old, New: new})
+		}
+	}
+
+	add("title", prev.Title, cur.Title)
+	add("description", prev.Description, cur.Description)
+	add("category", prev.Category, cur.Category)
+	add("rarity", prev.Rarity, cur.Rarity)
+	add("difficulty", prev.Difficulty, cur.Difficulty)
+	add("price", prev.Price, cur.Price)
+	add("conditions_json", rawString(prev.ConditionsJson), rawString(cur.ConditionsJson))
+	add("bonus_json", rawString(prev.BonusJson), rawString(cur.BonusJson))
+	add("is_sequential", prev.IsSequential, cur.IsSequential)
+	add("requires_confirmation", prev.RequiresConfirmation, cur.RequiresConfirmation)
+	add("window_policy", prev.WindowPolicy, cur.WindowPolicy)
+	add("deadline_policy", prev.DeadlinePolicy, cur.DeadlinePolicy)
+	add("reduced_reward_percent", prev.ReducedRewardPercent, cur.ReducedRewardPercent)
+	add("reward_xp", prev.RewardXP, cur.RewardXP)
+	add("reward_coin", prev.RewardCoin, cur.RewardCoin)
+	add("time_limit_hours", prev.TimeLimitHours, cur.TimeLimitHours)
+
+	for i := 0; i < max(len(prev.Tasks), len(cur.Tasks)); i++ {
+		prefix := fmt.Sprintf("tasks[%d]", i)
+		switch {
+		case i >= len(cur.Tasks):
+			changes = append(changes, QuestChange{Field: prefix, Old: prev.Tasks[i].Title})
+		case i >= len(prev.Tasks):
+			changes = append(changes, QuestChange{Field: prefix, New: cur.Tasks[i].Title})
+		default:
+			p, c := &prev.Tasks[i], &cur.Tasks[i]
+			add(prefix+".title", p.Title, c.Title)
+			add(prefix+".description", p.Description, c.Description)
+			add(prefix+".difficulty", p.Difficulty, c.Difficulty)
+			add(prefix+".rarity", p.Rarity, c.Rarity)
+			add(prefix+".category", p.Category, c.Category)
+			add(prefix+".base_xp_reward", p.BaseXpReward, c.BaseXpReward)
+			add(prefix+".base_coin_reward", p.BaseCoinReward, c.BaseCoinReward)
+			add(prefix+".type", p.Type, c.Type)
+			add(prefix+".cooldown_hours", p.CooldownHours, c.CooldownHours)
+			add(prefix+".required_occurrences", p.RequiredOccurrences, c.RequiredOccurrences)
+			add(prefix+".proof_type", p.ProofType, c.ProofType)
+			add(prefix+".target_value", p.TargetValue, c.TargetValue)
+			add(prefix+".unit", p.Unit, c.Unit)
+		}
+	}
+
+	return changes
+}
+
+func rawString(raw *json.RawMessage) *string {
+	if raw == nil {
+		return nil
+	}
+	s := string(*raw)
+	return &s
+}
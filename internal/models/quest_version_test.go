@@ -0,0 +1,98 @@
+package models
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestDiffQuests(t *testing.T) {
+	raw := func(s string) *json.RawMessage {
+		r := json.RawMessage(s)
+		return &r
+	}
+	target := func(v float64) *float64 { return &v }
+
+	base := func() *Quest {
+		return &Quest{
+			Title: "Марафон", Category: "health", Rarity: "common", Difficulty: 2, Price: 45,
+			RewardXP: 45, RewardCoin: 30,
+			Tasks: []Task{
+				{Title: "Пробежка", Difficulty: 2, BaseXpReward: 30, BaseCoinReward: 20, TargetValue: target(5)},
+			},
+		}
+	}
+
+	tests := []struct {
+		name   string
+		modify func(q *Quest)
+		want   []QuestChange
+	}{
+		{
+			name:   "same quest",
+			modify: func(q *Quest) {},
+			want:   []QuestChange{},
+		},
+		{
+			name: "quest fields",
+			modify: func(q *Quest) {
+				q.Title = "Большой марафон"
+				q.Price = 60
+			},
+			want: []QuestChange{
+				{Field: "title", Old: "Марафон", New: "Большой марафон"},
+				{Field: "price", Old: 45, New: 60},
+			},
+		},
+		{
+			name: "conditions added",
+			modify: func(q *Quest) {
+				q.ConditionsJson = raw(`{"min_level":3}`)
+			},
+			want: []QuestChange{
+				{Field: "conditions_json", Old: (*string)(nil), New: rawString(raw(`{"min_level":3}`))},
+			},
+		},
+		{
+			name: "task fields",
+			modify: func(q *Quest) {
+				q.Tasks[0].BaseXpReward = 40
+				q.Tasks[0].TargetValue = target(10)
+			},
+			want: []QuestChange{
+				{Field: "tasks[0].base_xp_reward", Old: 30, New: 40},
+				{Field: "tasks[0].target_value", Old: target(5), New: target(10)},
+			},
+		},
+		{
+			name: "task added",
+			modify: func(q *Quest) {
+				q.Tasks = append(q.Tasks, Task{Title: "Заплыв"})
+			},
+			want: []QuestChange{
+				{Field: "tasks[1]", New: "Заплыв"},
+			},
+		},
+		{
+			name: "task removed",
+			modify: func(q *Quest) {
+				q.Tasks = nil
+			},
+			want: []QuestChange{
+				{Field: "tasks[0]", Old: "Пробежка"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cur := base()
+			tt.modify(cur)
+
+			got := DiffQuests(base(), cur)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DiffQuests() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,21 @@
+package models
+
+// Кому виден квест (quests.visibility)
+const (
+	QuestVisibilityPrivate = "private" // только автору
+	QuestVisibilityFriends = "friends" // автору и его друзьям
+	QuestVisibilityPublic  = "public"  // всем
+)
+
+// IsValidQuestVisibility - известна ли видимость квеста
+func IsValidQuestVisibility(visibility string) bool {
+	switch visibility {
+	case QuestVisibilityPrivate, QuestVisibilityFriends, QuestVisibilityPublic:
+		return true
+	}
+	return false
+}
+
+type SetQuestVisibilityRequest struct {
+	Visibility string `json:"visibility" binding:"required,oneof=private friends public"`
+}
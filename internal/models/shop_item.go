@@ -0,0 +1,61 @@
+package models
+
+import "errors"
+
+// Типы предметов магазина
+const (
+	// Заморозка серии: сохраняет current_streak за один пропущенный день
+	ItemStreakFreeze = "streak_freeze"
+	// Усилитель опыта: множитель XP на duration_hours часов
+	ItemXPBooster = "xp_booster"
+	// Продление срока: сдвигает срок (expires_at) начатого квеста на duration_hours часов
+	ItemDeadlineExtension = "deadline_extension"
+)
+
+var ErrItemQuestRequired = errors.New("quest_id is required for a deadline extension")
+
+// ShopItem - расходуемый предмет из каталога магазина
+type ShopItem struct {
+	ID            int      `json:"id" db:"id"`
+	Code          string   `json:"code" db:"code"`
+	Name          string   `json:"name" db:"name"`
+	Description   *string  `json:"description" db:"description"`
+	ItemType      string   `json:"item_type" db:"item_type"`
+	Price         int      `json:"price" db:"price"`
+	Multiplier    *float64 `json:"multiplier,omitempty" db:"multiplier"`
+	DurationHours *int     `json:"duration_hours,omitempty" db:"duration_hours"`
+	IsActive      bool     `json:"is_active" db:"is_active"`
+}
+
+// InventoryItem - предмет в инвентаре пользователя
+type InventoryItem struct {
+	ShopItem
+	Quantity int `json:"quantity" db:"quantity"`
+}
+
+type PurchaseItemRequest struct {
+	Quantity int `json:"quantity" binding:"omitempty,min=1,max=100"` // по умолчанию 1
+}
+
+type UseItemRequest struct {
+	QuestID *int `json:"quest_id"` // для deadline_extension
+}
+
+// Effect - длящийся эффект (user_effects), который выдается при использовании предмета.
+// nil - у предмета мгновенное действие (deadline_extension).
+func (i ShopItem) Effect() *BonusEffect {
+	switch i.ItemType {
+	case ItemStreakFreeze:
+		return &BonusEffect{Type: EffectStreakFreeze, Count: 1}
+	case ItemXPBooster:
+		effect := &BonusEffect{Type: EffectXPMultiplier, Multiplier: 1}
+		if i.Multiplier != nil {
+			effect.Multiplier = *i.Multiplier
+		}
+		if i.DurationHours != nil {
+			effect.DurationHours = *i.DurationHours
+		}
+		return effect
+	}
+	return nil
+}
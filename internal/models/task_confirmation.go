@@ -0,0 +1,41 @@
+package models
+
+import "time"
+
+// Статусы запроса на подтверждение выполнения задачи (task_confirmations.status)
+const (
+	ConfirmationPending  = "pending"
+	ConfirmationApproved = "approved"
+	ConfirmationRejected = "rejected"
+)
+
+// TaskConfirmation - запрос другу (confirmer) подтвердить выполнение задачи в квесте с requires_confirmation
+type TaskConfirmation struct {
+	ID            int        `json:"id" db:"id"`
+	UserID        int        `json:"user_id" db:"user_id"`
+	ConfirmerID   int        `json:"confirmer_id" db:"confirmer_id"`
+	TaskID        int        `json:"task_id" db:"task_id"`
+	QuestID       int        `json:"quest_id" db:"quest_id"`
+	Attempt       int        `json:"attempt" db:"attempt"`
+	OccurrenceID  *int       `json:"occurrence_id,omitempty" db:"occurrence_id"`
+	Status        string     `json:"status" db:"status"`
+	Reason        *string    `json:"reason,omitempty" db:"reason"`
+	RewardPercent int        `json:"reward_percent" db:"reward_percent"`
+	CreatedAt     time.Time  `json:"created_at" db:"created_at"`
+	DecidedAt     *time.Time `json:"decided_at,omitempty" db:"decided_at"`
+
+	// Для списка подтверждений у друга
+	Username    string           `json:"username,omitempty" db:"username"`
+	TaskTitle   string           `json:"task_title,omitempty" db:"task_title"`
+	QuestTitle  string           `json:"quest_title,omitempty" db:"quest_title"`
+	Submissions []TaskSubmission `json:"submissions,omitempty" db:"-"`
+}
+
+type SetQuestConfirmerRequest struct {
+	FriendID int `json:"friend_id" binding:"required"`
+}
+
+type DecideTaskConfirmationRequest struct {
+	Status string  `json:"status" binding:"required,oneof=approved rejected"`
+	Reason *string `json:"reason"`
+}
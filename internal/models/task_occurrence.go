@@ -0,0 +1,31 @@
+package models
+
+import "time"
+
+// Типы задач (enum task_type)
+const (
+	TaskTypeDaily         = "daily"
+	TaskTypeWeekly        = "weekly"
+	TaskTypeSpecial       = "special"
+	TaskTypeUserGenerated = "user_generated"
+)
+
+// IsRecurringTaskType - повторяется ли задача каждый период
+func IsRecurringTaskType(taskType string) bool {
+	return taskType == TaskTypeDaily || taskType == TaskTypeWeekly
+}
+
+// TaskOccurrence - выполнение повторяющейся задачи за один период (день / неделю)
+type TaskOccurrence struct {
+	ID          int        `json:"id" db:"id"`
+	UserID      int        `json:"user_id" db:"user_id"`
+	TaskID      int        `json:"task_id" db:"task_id"`
+	QuestID     int        `json:"quest_id" db:"quest_id"`
+	Attempt     int        `json:"attempt" db:"attempt"`
+	PeriodStart time.Time  `json:"period_start" db:"period_start"`
+	PeriodEnd   time.Time  `json:"period_end" db:"period_end"`
+	Status      string     `json:"status" db:"status"`
+	CompletedAt *time.Time `json:"completed_at" db:"completed_at"`
+	XpGained    int        `json:"xp_gained" db:"xp_gained"`
+	CoinGained  int        `json:"coin_gained" db:"coin_gained"`
+}
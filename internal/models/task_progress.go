@@ -0,0 +1,32 @@
+package models
+
+import "math"
+
+// TaskProgress - прогресс пользователя по количественной задаче
+type TaskProgress struct {
+	QuestID         int     `json:"quest_id" db:"quest_id"`
+	TaskID          int     `json:"task_id" db:"task_id"`
+	Status          string  `json:"status" db:"status"`
+	ProgressValue   float64 `json:"progress_value" db:"progress_value"`
+	TargetValue     float64 `json:"target_value" db:"target_value"`
+	Unit            *string `json:"unit,omitempty" db:"unit"`
+	ProgressPercent float64 `json:"progress_percent" db:"-"`
+}
+
+// SetPercent пересчитывает ProgressPercent (не больше 100, с точностью до 0.1)
+func (p *TaskProgress) SetPercent() {
+	if p.TargetValue <= 0 {
+		p.ProgressPercent = 100
+		return
+	}
+	p.ProgressPercent = math.Min(100, math.Round(p.ProgressValue*1000/p.TargetValue)/10)
+}
+
+// TargetReached - достигнута ли цель задачи
+func (p *TaskProgress) TargetReached() bool {
+	return p.ProgressValue >= p.TargetValue
+}
+
+type AddTaskProgressRequest struct {
+	Value float64 `json:"value" binding:"required"`
+}
@@ -0,0 +1,17 @@
+package models
+
+// Политики квеста для задач, выполненных вне запланированного окна или после дедлайна
+const (
+	SchedulePolicyAllow  = "allow"  // засчитывать как обычно
+	SchedulePolicyReduce = "reduce" // засчитывать с уменьшенной наградой (quests.reduced_reward_percent)
+	SchedulePolicyReject = "reject" // не засчитывать
+)
+
+// IsValidSchedulePolicy - известна ли политика
+func IsValidSchedulePolicy(policy string) bool {
+	switch policy {
+	case SchedulePolicyAllow, SchedulePolicyReduce, SchedulePolicyReject:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,55 @@
+package models
+
+import (
+	"io"
+	"time"
+)
+
+// Какое доказательство выполнения требует задача (tasks.proof_type)
+const (
+	ProofTypeNone   = "none"
+	ProofTypeText   = "text"
+	ProofTypeNumber = "number"
+	ProofTypeFile   = "file"
+)
+
+// IsValidProofType - известен ли тип доказательства
+func IsValidProofType(proofType string) bool {
+	switch proofType {
+	case ProofTypeNone, ProofTypeText, ProofTypeNumber, ProofTypeFile:
+		return true
+	}
+	return false
+}
+
+// TaskSubmission - доказательство выполнения задачи.
+// Пока задача не выполнена, доказательство в статусе pending, при выполнении становится accepted.
+type TaskSubmission struct {
+	ID           int        `json:"id" db:"id"`
+	UserID       int        `json:"user_id" db:"user_id"`
+	TaskID       int        `json:"task_id" db:"task_id"`
+	QuestID      int        `json:"quest_id" db:"quest_id"`
+	Attempt      int        `json:"attempt" db:"attempt"`
+	OccurrenceID *int       `json:"occurrence_id,omitempty" db:"occurrence_id"`
+	ProofType    string     `json:"proof_type" db:"proof_type"`
+	TextValue    *string    `json:"text_value,omitempty" db:"text_value"`
+	NumericValue *float64   `json:"numeric_value,omitempty" db:"numeric_value"`
+	FileKey      *string    `json:"-" db:"file_key"`
+	FileName     *string    `json:"file_name,omitempty" db:"file_name"`
+	ContentType  *string    `json:"content_type,omitempty" db:"content_type"`
+	Status       string     `json:"status" db:"status"` // pending, accepted, rejected
+	CreatedAt    time.Time  `json:"created_at" db:"created_at"`
+	AcceptedAt   *time.Time `json:"accepted_at,omitempty" db:"accepted_at"`
+}
+
+type SubmitTaskProofRequest struct {
+	Text  *string  `json:"text" form:"text"`
+	Value *float64 `json:"value" form:"value"`
+}
+
+// TaskProofFile - загруженный файл доказательства
+type TaskProofFile struct {
+	Reader      io.Reader
+	FileName    string
+	ContentType string
+}
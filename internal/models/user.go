@@ -19,8 +19,11 @@ type User struct {
 	CharismaLevel     int `json:"charisma_level" db:"charisma_level"`
 	WillpowerLevel    int `json:"willpower_level" db:"willpower_level"`
 
-	CurrentStreak int `json:"current_streak" db:"current_streak"`
-	LongestStreak int `json:"longest_streak" db:"longest_streak"`
+	CurrentStreak  int        `json:"current_streak" db:"current_streak"`
+	LongestStreak  int        `json:"longest_streak" db:"longest_streak"`
+	LastStreakDate *time.Time `json:"last_streak_date" db:"last_streak_date"`
+
+	IsModerator bool `json:"is_moderator" db:"is_moderator"`
 
 	CreatedAt    time.Time `json:"created_at" db:"created_at"`
 	LastActiveAt time.Time `json:"last_active_at" db:"last_active_at"`
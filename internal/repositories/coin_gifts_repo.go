@@ -0,0 +1,108 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+
+	"BecomeOverMan/internal/models"
+)
+
+var (
+	ErrGiftToSelf            = errors.New("can't send coins to yourself")
+	ErrGiftNotFriends        = errors.New("coins can be sent only to friends")
+	ErrGiftDailyLimit        = errors.New("daily coin gift limit exceeded")
+	ErrGiftRecipientNotFound = errors.New("gift recipient not found")
+)
+
+// SendCoinGift переводит amount монет от senderID другу recipientID, если за текущие сутки отправитель
+// перевел не больше dailyLimit монет. Списание и зачисление - две транзакции журнала в одной транзакции БД.
+// Оба пользователя блокируются (FOR UPDATE) в порядке id, поэтому параллельные переводы
+// не превысят ни баланс, ни лимит и не приведут к взаимной блокировке.
+func (r *UserRepository) SendCoinGift(ctx context.Context, senderID, recipientID int, req models.SendCoinGiftRequest, dailyLimit int) (*models.CoinGift, error) {
+	if senderID == recipientID {
+		return nil, ErrGiftToSelf
+	}
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var users []struct {
+		ID       int    `db:"id"`
+		Username string `db:"username"`
+	}
+	err = tx.SelectContext(ctx, &users, `
+		SELECT id, username FROM users WHERE id IN ($1, $2) ORDER BY id FOR UPDATE
+	`, senderID, recipientID)
+	if err != nil {
+		return nil, err
+	}
+	if len(users) != 2 {
+		return nil, ErrGiftRecipientNotFound
+	}
+
+	// FOR SHARE на строке дружбы не даст удалить ее, пока перевод не завершится
+	isFriend, err := isFriends(ctx, tx, senderID, recipientID, true)
+	if err != nil {
+		return nil, err
+	}
+	if !isFriend {
+		return nil, ErrGiftNotFriends
+	}
+	usernames := map[int]string{users[0].ID: users[0].Username, users[1].ID: users[1].Username}
+
+	// Сколько отправитель уже перевел за сутки
+	var sentToday int
+	err = tx.GetContext(ctx, &sentToday, `
+		SELECT COALESCE(SUM(amount), 0) FROM coin_gifts
+		WHERE sender_id = $1 AND created_at >= date_trunc('day', NOW())
+	`, senderID)
+	if err != nil {
+		return nil, err
+	}
+	if sentToday+req.Amount > dailyLimit {
+		return nil, ErrGiftDailyLimit
+	}
+
+	gift := &models.CoinGift{}
+	err = tx.GetContext(ctx, gift, `
+		INSERT INTO coin_gifts (sender_id, recipient_id, amount, message)
+		VALUES ($1, $2, $3, $4)
+		RETURNING *
+	`, senderID, recipientID, req.Amount, req.Message)
+	if err != nil {
+		return nil, err
+	}
+
+	// Списание (при нехватке монет - ErrNotEnoughCoins) и зачисление
+	_, err = writeCoinTransaction(ctx, tx, coinEntry{
+		UserID:        senderID,
+		Amount:        -req.Amount,
+		Type:          models.CoinTxGift,
+		ReferenceType: models.CoinRefGift,
+		ReferenceID:   gift.ID,
+		Description:   "Gift to " + usernames[recipientID],
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = writeCoinTransaction(ctx, tx, coinEntry{
+		UserID:        recipientID,
+		Amount:        req.Amount,
+		Type:          models.CoinTxGift,
+		ReferenceType: models.CoinRefGift,
+		ReferenceID:   gift.ID,
+		Description:   "Gift from " + usernames[senderID],
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return gift, nil
+}
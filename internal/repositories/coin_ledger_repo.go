@@ -0,0 +1,106 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strconv"
+
+	"BecomeOverMan/internal/models"
+
+	"github.com/jmoiron/sqlx"
+)
+
+var ErrNotEnoughCoins = errors.New("not enough currency")
+
+// coinEntry - изменение баланса монет пользователя для журнала
+type coinEntry struct {
+	UserID        int
+	Amount        int    // > 0 - начисление, < 0 - списание
+	Type          string // models.CoinTx*
+	ReferenceType string // models.CoinRef*
+	ReferenceID   int
+	Description   string
+}
+
+// writeCoinTransaction - единственный способ изменить users.coin_balance: меняет баланс на entry.Amount
+// и записывает транзакцию в user_coin_transactions в той же транзакции БД. Списание, после которого
+// баланс стал бы отрицательным, возвращает ErrNotEnoughCoins. Нулевая сумма ничего не меняет.
+// Возвращает баланс после транзакции.
+func writeCoinTransaction(ctx context.Context, tx *sqlx.Tx, entry coinEntry) (int, error) {
+	var balance int
+	if entry.Amount == 0 {
+		err := tx.GetContext(ctx, &balance, `SELECT coin_balance FROM users WHERE id = $1`, entry.UserID)
+		return balance, err
+	}
+
+	err := tx.GetContext(ctx, &balance, `
+		UPDATE users SET coin_balance = coin_balance + $1
+		WHERE id = $2 AND coin_balance + $1 >= 0
+		RETURNING coin_balance
+	`, entry.Amount, entry.UserID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, ErrNotEnoughCoins
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO user_coin_transactions
+		(user_id, amount, balance_after, transaction_type, reference_type, reference_id, description)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`, entry.UserID, entry.Amount, balance, entry.Type, entry.ReferenceType, entry.ReferenceID, entry.Description)
+	if err != nil {
+		return 0, err
+	}
+
+	return balance, nil
+}
+
+// GetCoinTransactions возвращает историю транзакций пользователя от новых к старым.
+// Курсор - id последней транзакции предыдущей страницы.
+func (r *QuestRepository) GetCoinTransactions(ctx context.Context, userID int, filter models.CoinTransactionsFilter) (*models.CoinTransactionsPage, error) {
+	query := `SELECT * FROM user_coin_transactions WHERE user_id = $1`
+	args := []any{userID}
+	add := func(cond string, arg any) {
+		args = append(args, arg)
+		query += ` AND ` + cond + ` $` + strconv.Itoa(len(args))
+	}
+
+	if filter.Type != "" {
+		add(`transaction_type =`, filter.Type)
+	}
+	if filter.ReferenceType != "" {
+		add(`reference_type =`, filter.ReferenceType)
+	}
+	if filter.ReferenceID != nil {
+		add(`reference_id =`, *filter.ReferenceID)
+	}
+	if filter.From != nil {
+		add(`created_at >=`, *filter.From)
+	}
+	if filter.To != nil {
+		add(`created_at <`, *filter.To)
+	}
+	if filter.Cursor > 0 {
+		add(`id <`, filter.Cursor)
+	}
+
+	// Берем на одну запись больше, чтобы понять, есть ли следующая страница
+	args = append(args, filter.Limit+1)
+	query += ` ORDER BY id DESC LIMIT $` + strconv.Itoa(len(args))
+
+	page := &models.CoinTransactionsPage{Transactions: []models.CoinTransaction{}}
+	if err := r.db.SelectContext(ctx, &page.Transactions, query, args...); err != nil {
+		return nil, err
+	}
+
+	if len(page.Transactions) > filter.Limit {
+		page.Transactions = page.Transactions[:filter.Limit]
+		next := page.Transactions[filter.Limit-1].ID
+		page.NextCursor = &next
+	}
+
+	return page, nil
+}
@@ -0,0 +1,145 @@
+package repositories
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"time"
+
+	"BecomeOverMan/internal/models"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// loadActiveEffects возвращает эффекты, действующие на пользователя сейчас:
+// выданные ему (user_effects) и пассивные бонусы полученных достижений.
+// Принимает как *sqlx.DB, так и *sqlx.Tx.
+func loadActiveEffects(ctx context.Context, q sqlx.QueryerContext, userID int) ([]models.ActiveEffect, error) {
+	effects := []models.ActiveEffect{}
+	err := sqlx.SelectContext(ctx, q, &effects, `
+		SELECT id, user_id, effect_type, multiplier, category, uses_left, source_type, source_id, created_at, expires_at
+		FROM user_effects
+		WHERE user_id = $1
+		AND (expires_at IS NULL OR expires_at > NOW())
+		AND (uses_left IS NULL OR uses_left > 0)
+		ORDER BY created_at
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var achievements []struct {
+		ID         int              `db:"id"`
+		BonusJson  *json.RawMessage `db:"bonus_json"`
+		UnlockedAt time.Time        `db:"unlocked_at"`
+	}
+	err = sqlx.SelectContext(ctx, q, &achievements, `
+		SELECT a.id, a.bonus_json, ua.unlocked_at
+		FROM achievements a
+		INNER JOIN user_achievements ua ON ua.achievement_id = a.id
+		WHERE ua.user_id = $1 AND a.bonus_json IS NOT NULL
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Бонусы достижений действуют постоянно, пока достижение есть у пользователя
+	for _, a := range achievements {
+		bonuses, err := models.ParseBonusEffects(a.BonusJson)
+		if err != nil {
+			slog.WarnContext(ctx, "Skipping invalid achievement bonus_json", "achievement_id", a.ID, "error", err)
+			continue
+		}
+
+		for _, b := range bonuses {
+			if b.Type != models.EffectXPMultiplier && b.Type != models.EffectCoinMultiplier {
+				continue
+			}
+
+			multiplier := b.Multiplier
+			achievementID := a.ID
+			effect := models.ActiveEffect{
+				UserID:     userID,
+				EffectType: b.Type,
+				Multiplier: &multiplier,
+				SourceType: "achievement",
+				SourceID:   &achievementID,
+				CreatedAt:  a.UnlockedAt,
+			}
+			if b.Category != "" {
+				category := b.Category
+				effect.Category = &category
+			}
+
+			effects = append(effects, effect)
+		}
+	}
+
+	return effects, nil
+}
+
+// grantBonusEffects выдает пользователю длящиеся эффекты (множители, заморозки серии).
+// Мгновенные эффекты (early_completion) применяются в месте начисления награды и тут пропускаются.
+func grantBonusEffects(tx *sqlx.Tx, ctx context.Context, userID int, effects []models.BonusEffect, sourceType string, sourceID int) error {
+	for _, e := range effects {
+		var (
+			multiplier *float64
+			category   *string
+			usesLeft   *int
+			expiresAt  *time.Time
+		)
+
+		switch e.Type {
+		case models.EffectXPMultiplier, models.EffectCoinMultiplier:
+			m := e.Multiplier
+			multiplier = &m
+			if e.Category != "" {
+				c := e.Category
+				category = &c
+			}
+			if e.DurationHours > 0 {
+				t := time.Now().Add(time.Duration(e.DurationHours) * time.Hour)
+				expiresAt = &t
+			}
+		case models.EffectStreakFreeze:
+			n := e.Count
+			usesLeft = &n
+		default:
+			continue
+		}
+
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO user_effects (user_id, effect_type, multiplier, category, uses_left, source_type, source_id, expires_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		`, userID, e.Type, multiplier, category, usesLeft, sourceType, sourceID, expiresAt)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// questBonusEffects читает bonus_json версии квеста, которую проходил пользователь. Некорректный bonus_json
+// не должен мешать пользователю получить награду, поэтому ошибка только логируется.
+func questBonusEffects(ctx context.Context, q sqlx.QueryerContext, userID, questID int) ([]models.BonusEffect, error) {
+	var raw *json.RawMessage
+	err := sqlx.GetContext(ctx, q, &raw,
+		`SELECT q.bonus_json FROM `+pinnedQuestSQL+` WHERE uq.user_id = $1 AND uq.quest_id = $2`, userID, questID)
+	if err != nil {
+		return nil, err
+	}
+
+	effects, err := models.ParseBonusEffects(raw)
+	if err != nil {
+		slog.WarnContext(ctx, "Skipping invalid quest bonus_json", "quest_id", questID, "error", err)
+		return nil, nil
+	}
+
+	return effects, nil
+}
+
+// GetActiveEffects возвращает эффекты, действующие на пользователя сейчас
+func (r *QuestRepository) GetActiveEffects(ctx context.Context, userID int) ([]models.ActiveEffect, error) {
+	return loadActiveEffects(ctx, r.db, userID)
+}
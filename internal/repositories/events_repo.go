@@ -0,0 +1,352 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"BecomeOverMan/internal/models"
+
+	"github.com/jmoiron/sqlx"
+)
+
+var (
+	ErrEventNotFound       = errors.New("event not found")
+	ErrEventClosed         = errors.New("event is already closed")
+	ErrEventNotActive      = errors.New("event quest is available only while the event is running")
+	ErrEventQuestTaken     = errors.New("quest already belongs to an event")
+	ErrEventQuestNotSystem = errors.New("only system quests can be event quests")
+	ErrEventQuestNotFound  = errors.New("quest is not part of the event")
+)
+
+// eventQuestOpenSQL - квест не относится к событию или его событие идет сейчас (алиас q - quests)
+const eventQuestOpenSQL = `NOT EXISTS (
+	SELECT 1 FROM event_quests eq
+	INNER JOIN events e ON e.id = eq.event_id
+	WHERE eq.quest_id = q.id AND NOT (e.starts_at <= NOW() AND NOW() < e.ends_at)
+)`
+
+// eventRankingSQL - текущая таблица лидеров события $1
+const eventRankingSQL = `
+	SELECT RANK() OVER (ORDER BY p.points DESC, p.reached_at) AS rank,
+	       p.user_id, u.username, p.points
+	FROM user_event_points p
+	INNER JOIN users u ON u.id = p.user_id
+	WHERE p.event_id = $1`
+
+// isEventQuestOpen - можно ли сейчас купить квест с учетом окна его события
+func isEventQuestOpen(ctx context.Context, q sqlx.QueryerContext, questID int) (bool, error) {
+	var open bool
+	err := sqlx.GetContext(ctx, q, &open, `SELECT `+eventQuestOpenSQL+` FROM quests q WHERE q.id = $1`, questID)
+	return open, err
+}
+
+// CreateEvent сохраняет событие
+func (r *QuestRepository) CreateEvent(ctx context.Context, event *models.Event) error {
+	rewards, err := json.Marshal(event.Rewards)
+	if err != nil {
+		return err
+	}
+	raw := json.RawMessage(rewards)
+	event.RewardsJson = &raw
+
+	err = r.db.GetContext(ctx, event, `
+		INSERT INTO events (title, description, starts_at, ends_at, rewards_json, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING *
+	`, event.Title, event.Description, event.StartsAt, event.EndsAt, event.RewardsJson, event.CreatedBy)
+	if err != nil {
+		return err
+	}
+
+	return event.Decode(time.Now())
+}
+
+// GetEvents возвращает события (сначала более поздние) с фильтром по статусу (см. models.EventStatus*)
+func (r *QuestRepository) GetEvents(ctx context.Context, status string) ([]models.Event, error) {
+	query := `SELECT * FROM events`
+	switch status {
+	case models.EventStatusUpcoming:
+		query += ` WHERE closed_at IS NULL AND NOW() < starts_at`
+	case models.EventStatusActive:
+		query += ` WHERE closed_at IS NULL AND starts_at <= NOW() AND NOW() < ends_at`
+	case models.EventStatusFinished:
+		query += ` WHERE closed_at IS NULL AND ends_at <= NOW()`
+	case models.EventStatusClosed:
+		query += ` WHERE closed_at IS NOT NULL`
+	}
+	query += ` ORDER BY starts_at DESC, id DESC`
+
+	events := []models.Event{}
+	if err := r.db.SelectContext(ctx, &events, query); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	for i := range events {
+		if err := events[i].Decode(now); err != nil {
+			return nil, err
+		}
+	}
+
+	return events, nil
+}
+
+// GetEvent возвращает событие с его квестами и местом пользователя в таблице лидеров
+func (r *QuestRepository) GetEvent(ctx context.Context, eventID, userID int) (*models.Event, error) {
+	var event models.Event
+	err := r.db.GetContext(ctx, &event, `SELECT * FROM events WHERE id = $1`, eventID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrEventNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := event.Decode(time.Now()); err != nil {
+		return nil, err
+	}
+
+	event.Quests = []models.EventQuest{}
+	err = r.db.SelectContext(ctx, &event.Quests, `
+		SELECT eq.quest_id, q.title, q.category, q.rarity, q.price, eq.points
+		FROM event_quests eq
+		INNER JOIN quests q ON q.id = eq.quest_id
+		WHERE eq.event_id = $1
+		ORDER BY eq.points, q.id
+	`, eventID)
+	if err != nil {
+		return nil, err
+	}
+
+	var standing models.EventStanding
+	if event.ClosedAt != nil {
+		err = r.db.GetContext(ctx, &standing, `
+			SELECT er.rank, er.user_id, u.username, er.points, er.reward_xp, er.reward_coin
+			FROM event_results er
+			INNER JOIN users u ON u.id = er.user_id
+			WHERE er.event_id = $1 AND er.user_id = $2
+		`, eventID, userID)
+	} else {
+		err = r.db.GetContext(ctx, &standing, `
+			SELECT * FROM (`+eventRankingSQL+`) ranking WHERE user_id = $2
+		`, eventID, userID)
+	}
+	if err == nil {
+		event.MyStanding = &standing
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+
+	return &event, nil
+}
+
+// GetEventLeaderboard возвращает первые limit мест события: итоговые для закрытого события, текущие - для идущего
+func (r *QuestRepository) GetEventLeaderboard(ctx context.Context, eventID, limit int) ([]models.EventStanding, error) {
+	var closed bool
+	err := r.db.GetContext(ctx, &closed, `SELECT closed_at IS NOT NULL FROM events WHERE id = $1`, eventID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrEventNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	standings := []models.EventStanding{}
+	if closed {
+		err = r.db.SelectContext(ctx, &standings, `
+			SELECT er.rank, er.user_id, u.username, er.points, er.reward_xp, er.reward_coin
+			FROM event_results er
+			INNER JOIN users u ON u.id = er.user_id
+			WHERE er.event_id = $1
+			ORDER BY er.rank, er.user_id
+			LIMIT $2
+		`, eventID, limit)
+	} else {
+		err = r.db.SelectContext(ctx, &standings, eventRankingSQL+`
+			ORDER BY rank, p.user_id
+			LIMIT $2
+		`, eventID, limit)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return standings, nil
+}
+
+// AddEventQuest добавляет системный квест в незакрытое событие (или меняет его очки)
+func (r *QuestRepository) AddEventQuest(ctx context.Context, eventID, questID, points int) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := lockOpenEvent(ctx, tx, eventID); err != nil {
+		return err
+	}
+
+	var authorID *int
+	err = tx.GetContext(ctx, &authorID, `SELECT author_id FROM quests WHERE id = $1`, questID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrQuestNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if authorID != nil {
+		return ErrEventQuestNotSystem
+	}
+
+	res, err := tx.ExecContext(ctx, `
+		INSERT INTO event_quests (event_id, quest_id, points)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (quest_id) DO UPDATE SET points = EXCLUDED.points
+		WHERE event_quests.event_id = EXCLUDED.event_id
+	`, eventID, questID, points)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrEventQuestTaken
+	}
+
+	return tx.Commit()
+}
+
+// RemoveEventQuest убирает квест из незакрытого события
+func (r *QuestRepository) RemoveEventQuest(ctx context.Context, eventID, questID int) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := lockOpenEvent(ctx, tx, eventID); err != nil {
+		return err
+	}
+
+	res, err := tx.ExecContext(ctx, `DELETE FROM event_quests WHERE event_id = $1 AND quest_id = $2`, eventID, questID)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrEventQuestNotFound
+	}
+
+	return tx.Commit()
+}
+
+// lockOpenEvent блокирует событие, которое еще не закрыто
+func lockOpenEvent(ctx context.Context, tx *sqlx.Tx, eventID int) error {
+	var closed bool
+	err := tx.GetContext(ctx, &closed, `SELECT closed_at IS NOT NULL FROM events WHERE id = $1 FOR UPDATE`, eventID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrEventNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if closed {
+		return ErrEventClosed
+	}
+	return nil
+}
+
+// awardEventPoints начисляет очки события за квест, завершенный во время события.
+// FOR SHARE на событии: CloseFinishedEvent (FOR UPDATE SKIP LOCKED) не подведет итоги,
+// пока начисление не зафиксировано, а начисление, дождавшееся закрытия, увидит closed_at и ничего не добавит.
+func awardEventPoints(tx *sqlx.Tx, ctx context.Context, userID, questID int) error {
+	_, err := tx.ExecContext(ctx, `
+		INSERT INTO user_event_points (event_id, user_id, points, reached_at)
+		SELECT eq.event_id, $1, eq.points, NOW()
+		FROM event_quests eq
+		INNER JOIN events e ON e.id = eq.event_id
+		WHERE eq.quest_id = $2 AND e.closed_at IS NULL
+		AND e.starts_at <= NOW() AND NOW() < e.ends_at
+		FOR SHARE OF e
+		ON CONFLICT (event_id, user_id) DO UPDATE
+		SET points = user_event_points.points + EXCLUDED.points, reached_at = EXCLUDED.reached_at
+	`, userID, questID)
+	return err
+}
+
+// CloseFinishedEvent подводит итоги одного закончившегося события: сохраняет итоговую таблицу
+// в event_results и выдает награды за места. Возвращает nil, если закрывать нечего.
+// FOR UPDATE SKIP LOCKED не дает двум инстансам приложения закрыть одно событие дважды.
+func (r *QuestRepository) CloseFinishedEvent(ctx context.Context) (*models.Event, []models.EventStanding, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer tx.Rollback()
+
+	var event models.Event
+	err = tx.GetContext(ctx, &event, `
+		SELECT * FROM events
+		WHERE closed_at IS NULL AND ends_at <= NOW()
+		ORDER BY ends_at
+		LIMIT 1
+		FOR UPDATE SKIP LOCKED
+	`)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := event.Decode(time.Now()); err != nil {
+		return nil, nil, err
+	}
+
+	var standings []models.EventStanding
+	if err := tx.SelectContext(ctx, &standings, eventRankingSQL+` ORDER BY rank, p.user_id`, event.ID); err != nil {
+		return nil, nil, err
+	}
+
+	for i := range standings {
+		s := &standings[i]
+		s.RewardXP, s.RewardCoin = models.EventRewardFor(event.Rewards, s.Rank)
+
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO event_results (event_id, user_id, rank, points, reward_xp, reward_coin)
+			VALUES ($1, $2, $3, $4, $5, $6)
+		`, event.ID, s.UserID, s.Rank, s.Points, s.RewardXP, s.RewardCoin)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if err := grantEventReward(tx, ctx, s.UserID, &event, s.RewardXP, s.RewardCoin); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, `UPDATE events SET closed_at = NOW() WHERE id = $1`, event.ID); err != nil {
+		return nil, nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, nil, err
+	}
+
+	return &event, standings, nil
+}
+
+// grantEventReward начисляет награду за место в событии (без множителей эффектов) с пересчетом уровня
+func grantEventReward(tx *sqlx.Tx, ctx context.Context, userID int, event *models.Event, xp, coins int) error {
+	if err := addXPWithLevelUp(tx, ctx, userID, xp); err != nil {
+		return err
+	}
+
+	_, err := writeCoinTransaction(ctx, tx, coinEntry{
+		UserID:        userID,
+		Amount:        coins,
+		Type:          models.CoinTxBonus,
+		ReferenceType: models.CoinRefEvent,
+		ReferenceID:   event.ID,
+		Description:   "Event reward: " + event.Title,
+	})
+	return err
+}
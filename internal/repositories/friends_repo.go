@@ -34,25 +34,31 @@ func (r *UserRepository) AddFriendbyName(userID int, friendName string) error {
 	return r.addFriend(userID, friendID)
 }
 
-// Проверяем, что дружба не существует
-func (r *UserRepository) isFriends(userID, friendID int) (bool, error) {
-	var friendshipExists bool
-	err := r.db.Get(&friendshipExists, `
-		SELECT EXISTS(SELECT 1 FROM friends WHERE (user_id = $1 AND friend_id = $2) OR (user_id = $2 AND friend_id = $1))`,
-		userID, friendID)
+// isFriends проверяет, что между пользователями есть запись о дружбе (в любом направлении),
+// при acceptedOnly - только принятая. Найденные строки блокируются FOR SHARE,
+// поэтому внутри транзакции дружбу не удалить до ее завершения.
+func isFriends(ctx context.Context, q sqlx.QueryerContext, userID, friendID int, acceptedOnly bool) (bool, error) {
+	var ids []int
+	err := sqlx.SelectContext(ctx, q, &ids, `
+		SELECT id FROM friends
+		WHERE ((user_id = $1 AND friend_id = $2) OR (user_id = $2 AND friend_id = $1))
+		AND (NOT $3 OR status = 'accepted')
+		FOR SHARE`,
+		userID, friendID, acceptedOnly)
 	if err != nil {
 		return false, err
 	}
 
-	return friendshipExists, nil
+	return len(ids) > 0, nil
 }
 
 func (r *UserRepository) addFriend(userID, friendID int) error {
-	isFriends, err := r.isFriends(userID, friendID)
+	// Проверяем, что дружба не существует
+	alreadyFriends, err := isFriends(context.Background(), r.db, userID, friendID, false)
 	if err != nil {
 		return err
 	}
-	if isFriends {
+	if alreadyFriends {
 		return ErrAlreadyFriends
 	}
 
@@ -116,13 +122,7 @@ func (r *QuestRepository) CreateSharedQuest(user1ID, user2ID, questID int) error
 	defer tx.Rollback()
 
 	// Проверяем, что пользователи друзья (проверяем оба направления)
-	var areFriends bool
-	err = tx.Get(&areFriends, `
-		SELECT EXISTS(
-			SELECT 1 FROM friends 
-			WHERE ((user_id = $1 AND friend_id = $2) OR (user_id = $2 AND friend_id = $1))
-			AND status = 'accepted'
-		)`, user1ID, user2ID)
+	areFriends, err := isFriends(ctx, tx, user1ID, user2ID, true)
 	if err != nil {
 		return err
 	}
@@ -130,6 +130,35 @@ func (r *QuestRepository) CreateSharedQuest(user1ID, user2ID, questID int) error
 		return errors.New("users are not friends")
 	}
 
+	// Поделиться можно только одобренным модерацией квестом, который видят оба пользователя
+	for _, userID := range []int{user1ID, user2ID} {
+		visible, err := isQuestVisible(ctx, tx, questID, userID)
+		if err != nil {
+			return err
+		}
+		if !visible {
+			return ErrQuestNotFound
+		}
+	}
+
+	var moderationStatus string
+	err = tx.GetContext(ctx, &moderationStatus, `SELECT moderation_status FROM quests WHERE id = $1`, questID)
+	if err != nil {
+		return err
+	}
+	if moderationStatus != models.QuestModerationApproved {
+		return ErrQuestNotApproved
+	}
+
+	// Квест события можно начать только во время события
+	open, err := isEventQuestOpen(ctx, tx, questID)
+	if err != nil {
+		return err
+	}
+	if !open {
+		return ErrEventNotActive
+	}
+
 	// Создаем shared quest
 	_, err = tx.Exec(`
 		INSERT INTO shared_quests (user1_id, user2_id, quest_id, status) 
@@ -140,17 +169,17 @@ func (r *QuestRepository) CreateSharedQuest(user1ID, user2ID, questID int) error
 	}
 
 	// Стартуем квест для обоих пользователей
-	if err := r.startQuestForUser(tx, user1ID, questID); err != nil {
+	if err := r.startQuestForUser(ctx, tx, user1ID, questID); err != nil {
 		return err
 	}
-	if err := r.startQuestForUser(tx, user2ID, questID); err != nil {
+	if err := r.startQuestForUser(ctx, tx, user2ID, questID); err != nil {
 		return err
 	}
 
 	return tx.Commit()
 }
 
-func (r *QuestRepository) startQuestForUser(tx *sqlx.Tx, userID, questID int) error {
+func (r *QuestRepository) startQuestForUser(ctx context.Context, tx *sqlx.Tx, userID, questID int) error {
 	// Покупаем квест (если еще не куплен)
 	var alreadyPurchased bool
 	err := tx.Get(&alreadyPurchased, `
@@ -164,37 +193,44 @@ func (r *QuestRepository) startQuestForUser(tx *sqlx.Tx, userID, questID int) er
 		return errors.New("quest already purchased")
 	}
 
-	// Получаем цену квеста
-	var price int
-	err = tx.Get(&price, "SELECT price FROM quests WHERE id = $1", questID)
+	// Получаем последнюю версию квеста
+	var quest models.Quest
+	err = tx.GetContext(ctx, &quest, "SELECT * FROM quests WHERE id = $1 FOR SHARE", questID)
 	if err != nil {
 		return err
 	}
+	version := quest.Version
 
-	// Проверяем баланс
-	var balance int
-	err = tx.Get(&balance, "SELECT coin_balance FROM users WHERE id = $1", userID)
+	// Условия доступности квеста (уровень, пройденные квесты) проверяются для каждого участника
+	state, err := loadConditionsState(ctx, tx, userID)
 	if err != nil {
 		return err
 	}
+	if reasons := questLockedReasons(&quest, state); len(reasons) > 0 {
+		return &ErrQuestLocked{Reasons: reasons}
+	}
 
-	if balance < price {
+	// Списываем монеты
+	_, err = writeCoinTransaction(ctx, tx, coinEntry{
+		UserID:        userID,
+		Amount:        -quest.Price,
+		Type:          models.CoinTxSpent,
+		ReferenceType: models.CoinRefSharedQuest,
+		ReferenceID:   questID,
+		Description:   "Started shared quest: " + quest.Title,
+	})
+	if errors.Is(err, ErrNotEnoughCoins) {
 		return errors.New("not enough coins for shared quest")
 	}
-
-	// Покупаем квест
-	_, err = tx.Exec(`
-			INSERT INTO user_quests (user_id, quest_id, status) 
-			VALUES ($1, $2, 'purchased')`,
-		userID, questID)
 	if err != nil {
 		return err
 	}
 
-	// Списываем монеты
+	// Покупаем квест
 	_, err = tx.Exec(`
-			UPDATE users SET coin_balance = coin_balance - $1 WHERE id = $2`,
-		price, userID)
+			INSERT INTO user_quests (user_id, quest_id, status, quest_version) 
+			VALUES ($1, $2, 'purchased', $3)`,
+		userID, questID, version)
 	if err != nil {
 		return err
 	}
@@ -204,9 +240,9 @@ func (r *QuestRepository) startQuestForUser(tx *sqlx.Tx, userID, questID int) er
 		INSERT INTO user_tasks (user_id, task_id, quest_id, status)
 		SELECT $1, qt.task_id, qt.quest_id, 'not_started'
 		FROM quest_tasks qt
-		WHERE qt.quest_id = $2
+		WHERE qt.quest_id = $2 AND qt.version = $3
 		ORDER BY qt.task_order
-	`, userID, questID)
+	`, userID, questID, version)
 	if err != nil {
 		return err
 	}
@@ -215,11 +251,13 @@ func (r *QuestRepository) startQuestForUser(tx *sqlx.Tx, userID, questID int) er
 	_, err = tx.Exec(`
 			UPDATE user_quests 
 			SET status = 'started', started_at = NOW(), expires_at = (
-				SELECT NOW() + (time_limit_hours || ' hours')::interval 
-				FROM quests WHERE id = $2
+				SELECT CASE WHEN time_limit_hours > 0
+					THEN NOW() + (time_limit_hours || ' hours')::interval
+				END
+				FROM quest_versions WHERE quest_id = $2 AND version = $3
 			)
 			WHERE user_id = $1 AND quest_id = $2`,
-		userID, questID)
+		userID, questID, version)
 	if err != nil {
 		return err
 	}
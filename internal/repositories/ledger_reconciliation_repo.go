@@ -0,0 +1,138 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+
+	"BecomeOverMan/internal/models"
+
+	"github.com/jmoiron/sqlx"
+)
+
+var ErrNegativeRecordedBalance = errors.New("ledger sum is negative, balance can't be repaired from it")
+
+// ledgerDiscrepanciesSQL - расхождения users.coin_balance / users.xp_points с суммой записанных начислений
+// для пользователя $1 (0 - для всех). Опыт прошлых попыток квестов берется из user_quest_attempts
+// (включая задачи из tasks_snapshot), корректировки журнала опыта - из ledger_adjustments.
+const ledgerDiscrepanciesSQL = `
+	WITH coins AS (
+		SELECT user_id, SUM(amount) AS total
+		FROM user_coin_transactions
+		WHERE $1 = 0 OR user_id = $1
+		GROUP BY user_id
+	), xp AS (
+		SELECT user_id, SUM(xp) AS total FROM (
+			SELECT user_id, xp_gained AS xp FROM user_tasks
+			UNION ALL
+			SELECT user_id, COALESCE(xp_gained, 0) FROM user_quests
+			UNION ALL
+			SELECT a.user_id, COALESCE(a.xp_gained, 0) + COALESCE((
+				SELECT SUM((t->>'xp_gained')::int) FROM jsonb_array_elements(a.tasks_snapshot) t
+			), 0)
+			FROM user_quest_attempts a
+			UNION ALL
+			SELECT user_id, reward_xp FROM event_results
+			UNION ALL
+			SELECT user_id, delta FROM ledger_adjustments WHERE kind = 'xp' AND repair = 'ledger'
+		) awards
+		WHERE $1 = 0 OR user_id = $1
+		GROUP BY user_id
+	), totals AS (
+		SELECT u.id AS user_id, u.username, 'coins' AS kind,
+			COALESCE(u.coin_balance, 0) AS balance, COALESCE(c.total, 0) AS recorded
+		FROM users u
+		LEFT JOIN coins c ON c.user_id = u.id
+		WHERE $1 = 0 OR u.id = $1
+		UNION ALL
+		SELECT u.id, u.username, 'xp', COALESCE(u.xp_points, 0), COALESCE(x.total, 0)
+		FROM users u
+		LEFT JOIN xp x ON x.user_id = u.id
+		WHERE $1 = 0 OR u.id = $1
+	)
+	SELECT user_id, username, kind, balance, recorded, balance - recorded AS delta
+	FROM totals
+	WHERE balance <> recorded
+	ORDER BY user_id, kind`
+
+// GetLedgerDiscrepancies сверяет балансы пользователя userID (0 - всех пользователей) с журналом
+func (r *QuestRepository) GetLedgerDiscrepancies(ctx context.Context, userID int) ([]models.LedgerDiscrepancy, error) {
+	discrepancies := []models.LedgerDiscrepancy{}
+	err := r.db.SelectContext(ctx, &discrepancies, ledgerDiscrepanciesSQL, userID)
+	return discrepancies, err
+}
+
+// RepairLedgerDiscrepancy заново сверяет kind пользователя под блокировкой и исправляет расхождение:
+// repair = balance приводит users к сумме по журналу, repair = ledger дописывает в журнал корректировку.
+// Каждое исправление сохраняется в ledger_adjustments. Возвращает nil, если расхождения уже нет.
+func (r *QuestRepository) RepairLedgerDiscrepancy(ctx context.Context, userID int, kind, repair string) (*models.LedgerDiscrepancy, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// Блокируем пользователя, чтобы баланс не менялся во время исправления
+	if _, err := tx.ExecContext(ctx, `SELECT 1 FROM users WHERE id = $1 FOR UPDATE`, userID); err != nil {
+		return nil, err
+	}
+
+	var discrepancies []models.LedgerDiscrepancy
+	if err := tx.SelectContext(ctx, &discrepancies, ledgerDiscrepanciesSQL, userID); err != nil {
+		return nil, err
+	}
+
+	var d *models.LedgerDiscrepancy
+	for i := range discrepancies {
+		if discrepancies[i].Kind == kind {
+			d = &discrepancies[i]
+		}
+	}
+	if d == nil {
+		return nil, nil
+	}
+
+	if repair == models.LedgerRepairBalance && d.Recorded < 0 {
+		return nil, ErrNegativeRecordedBalance
+	}
+
+	var adjustmentID int
+	err = tx.GetContext(ctx, &adjustmentID, `
+		INSERT INTO ledger_adjustments (user_id, kind, repair, balance, recorded, delta)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id
+	`, userID, kind, repair, d.Balance, d.Recorded, d.Delta)
+	if err != nil {
+		return nil, err
+	}
+	d.AdjustmentID = &adjustmentID
+
+	switch {
+	case repair == models.LedgerRepairBalance && kind == models.LedgerKindCoins:
+		_, err = tx.ExecContext(ctx, `UPDATE users SET coin_balance = $1 WHERE id = $2`, d.Recorded, userID)
+	case repair == models.LedgerRepairBalance && kind == models.LedgerKindXP:
+		_, err = tx.ExecContext(ctx, `UPDATE users SET xp_points = $1, level = $2 WHERE id = $3`,
+			d.Recorded, calculateLevel(d.Recorded), userID)
+	case repair == models.LedgerRepairLedger && kind == models.LedgerKindCoins:
+		err = appendCoinAdjustment(ctx, tx, userID, d.Delta, d.Balance, adjustmentID)
+	}
+	// Корректировка журнала опыта - сама запись ledger_adjustments (учитывается в ledgerDiscrepanciesSQL)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// appendCoinAdjustment дописывает в журнал корректировку без изменения баланса:
+// журнал догоняет users.coin_balance, который изменился мимо writeCoinTransaction
+func appendCoinAdjustment(ctx context.Context, tx *sqlx.Tx, userID, amount, balance, adjustmentID int) error {
+	_, err := tx.ExecContext(ctx, `
+		INSERT INTO user_coin_transactions
+		(user_id, amount, balance_after, transaction_type, reference_type, reference_id, description)
+		VALUES ($1, $2, $3, $4, $5, $6, 'Ledger reconciliation')
+	`, userID, amount, balance, models.CoinTxAdjustment, models.CoinRefAdjustment, adjustmentID)
+	return err
+}
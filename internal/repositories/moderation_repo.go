@@ -0,0 +1,186 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"BecomeOverMan/internal/models"
+)
+
+var (
+	ErrNotModerator         = errors.New("only moderators can do this")
+	ErrQuestNotSubmittable  = errors.New("only draft or rejected quests can be submitted for review")
+	ErrQuestNotSubmitted    = errors.New("quest is not waiting for review")
+	ErrReportNotFound       = errors.New("report not found")
+	ErrReportedUserNotFound = errors.New("reported user not found")
+	ErrReportSelf           = errors.New("you can't report yourself")
+)
+
+// IsModerator - может ли пользователь проверять квесты и жалобы
+func (r *QuestRepository) IsModerator(ctx context.Context, userID int) (bool, error) {
+	var isModerator bool
+	err := r.db.GetContext(ctx, &isModerator, `SELECT is_moderator FROM users WHERE id = $1`, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	return isModerator, err
+}
+
+// SubmitQuest отправляет квест автора (черновик или отклоненный) на проверку модератору
+func (r *QuestRepository) SubmitQuest(ctx context.Context, questID, userID int) error {
+	if err := checkQuestAuthor(ctx, r.db, questID, userID); err != nil {
+		return err
+	}
+
+	res, err := r.db.ExecContext(ctx, `
+		UPDATE quests
+		SET moderation_status = 'submitted', submitted_at = NOW()
+		WHERE id = $1 AND moderation_status IN ('draft', 'rejected')
+	`, questID)
+	if err != nil {
+		return err
+	}
+
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrQuestNotSubmittable
+	}
+
+	return nil
+}
+
+// GetModerationQueue возвращает квесты, ожидающие проверки (сначала самые старые), вместе с задачами
+func (r *QuestRepository) GetModerationQueue(ctx context.Context) ([]models.Quest, error) {
+	quests := []models.Quest{}
+	err := r.db.SelectContext(ctx, &quests, `
+		SELECT * FROM quests
+		WHERE moderation_status = 'submitted'
+		ORDER BY submitted_at, id
+	`)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range quests {
+		if err := attachDefinitionTasks(ctx, r.db, &quests[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	return quests, nil
+}
+
+// ReviewQuest одобряет или отклоняет (с причиной) отправленный на проверку квест.
+// Свои квесты модератор проверять не может. Возвращает проверенный квест.
+func (r *QuestRepository) ReviewQuest(ctx context.Context, questID, moderatorID int, approve bool, reason *string) (*models.Quest, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var quest models.Quest
+	err = tx.GetContext(ctx, &quest, `SELECT * FROM quests WHERE id = $1 FOR UPDATE`, questID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrQuestNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if quest.ModerationStatus != models.QuestModerationSubmitted {
+		return nil, ErrQuestNotSubmitted
+	}
+	if quest.AuthorID != nil && *quest.AuthorID == moderatorID {
+		return nil, ErrQuestForbidden
+	}
+
+	status := models.QuestModerationApproved
+	if !approve {
+		status = models.QuestModerationRejected
+	}
+
+	err = tx.GetContext(ctx, &quest, `
+		UPDATE quests
+		SET moderation_status = $2, moderation_reason = $3, moderated_by = $4, moderated_at = NOW()
+		WHERE id = $1
+		RETURNING *
+	`, questID, status, reason, moderatorID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return &quest, nil
+}
+
+// CreateReport сохраняет жалобу на видимый пользователю квест или на другого пользователя
+func (r *QuestRepository) CreateReport(ctx context.Context, reporterID int, req models.CreateReportRequest) (*models.Report, error) {
+	if req.QuestID != nil {
+		visible, err := isQuestVisible(ctx, r.db, *req.QuestID, reporterID)
+		if err != nil {
+			return nil, err
+		}
+		if !visible {
+			return nil, ErrQuestNotFound
+		}
+	} else {
+		if *req.UserID == reporterID {
+			return nil, ErrReportSelf
+		}
+
+		var exists bool
+		err := r.db.GetContext(ctx, &exists, `SELECT EXISTS(SELECT 1 FROM users WHERE id = $1)`, *req.UserID)
+		if err != nil {
+			return nil, err
+		}
+		if !exists {
+			return nil, ErrReportedUserNotFound
+		}
+	}
+
+	var report models.Report
+	err := r.db.GetContext(ctx, &report, `
+		INSERT INTO reports (reporter_id, quest_id, reported_user_id, reason)
+		VALUES ($1, $2, $3, $4)
+		RETURNING *
+	`, reporterID, req.QuestID, req.UserID, req.Reason)
+	if err != nil {
+		return nil, err
+	}
+
+	return &report, nil
+}
+
+// GetReports возвращает жалобы с указанным статусом (сначала самые старые)
+func (r *QuestRepository) GetReports(ctx context.Context, status string) ([]models.Report, error) {
+	reports := []models.Report{}
+	err := r.db.SelectContext(ctx, &reports, `
+		SELECT * FROM reports
+		WHERE status = $1
+		ORDER BY created_at, id
+	`, status)
+	return reports, err
+}
+
+// ResolveReport закрывает открытую жалобу решением модератора
+func (r *QuestRepository) ResolveReport(ctx context.Context, reportID, moderatorID int, status string, resolution *string) (*models.Report, error) {
+	var report models.Report
+	err := r.db.GetContext(ctx, &report, `
+		UPDATE reports
+		SET status = $2, resolution = $3, resolved_by = $4, resolved_at = NOW()
+		WHERE id = $1 AND status = 'open'
+		RETURNING *
+	`, reportID, status, resolution, moderatorID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrReportNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &report, nil
+}
@@ -0,0 +1,153 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"BecomeOverMan/internal/models"
+)
+
+var (
+	ErrQuestNotFailed = errors.New("only failed quests can be retried")
+)
+
+// RetryQuest возвращает проваленный квест в статус purchased за retryPricePercent% от цены квеста.
+// Предыдущая попытка вместе со снимком задач сохраняется в user_quest_attempts,
+// а задачи пользователя сбрасываются в not_started.
+func (r *QuestRepository) RetryQuest(ctx context.Context, userID, questID, retryPricePercent int) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Блокируем строку квеста пользователя, чтобы параллельный retry не списал монеты дважды
+	var uqStatus string
+	err = tx.GetContext(ctx, &uqStatus, `
+		SELECT status FROM user_quests
+		WHERE user_id = $1 AND quest_id = $2
+		FOR UPDATE
+	`, userID, questID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrQuestNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	if uqStatus != "failed" {
+		return ErrQuestNotFailed
+	}
+
+	// Цена берется из версии квеста, которую проходит пользователь (retry ее не меняет)
+	var price int
+	var title string
+	err = tx.QueryRowContext(ctx, `
+		SELECT q.price, q.title FROM `+pinnedQuestSQL+`
+		WHERE uq.user_id = $1 AND uq.quest_id = $2
+	`, userID, questID).Scan(&price, &title)
+	if err != nil {
+		return err
+	}
+
+	retryPrice := price * retryPricePercent / 100
+
+	// Списываем валюту (при нехватке монет вся транзакция откатится)
+	_, err = writeCoinTransaction(ctx, tx, coinEntry{
+		UserID:        userID,
+		Amount:        -retryPrice,
+		Type:          models.CoinTxSpent,
+		ReferenceType: models.CoinRefQuestRetry,
+		ReferenceID:   questID,
+		Description:   "Retried quest: " + title,
+	})
+	if err != nil {
+		return err
+	}
+
+	// Сохраняем проваленную попытку в историю
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO user_quest_attempts (
+			user_id, quest_id, attempt, quest_version, status, xp_gained, coin_gained,
+			started_at, completed_at, expires_at, tasks_snapshot
+		)
+		SELECT
+			uq.user_id, uq.quest_id, uq.attempt, uq.quest_version, uq.status, uq.xp_gained, uq.coin_gained,
+			uq.started_at, uq.completed_at, uq.expires_at,
+			COALESCE((
+				SELECT json_agg(json_build_object(
+					'task_id', ut.task_id,
+					'status', ut.status,
+					'completed_at', ut.completed_at,
+					'xp_gained', ut.xp_gained,
+					'coin_gained', ut.coin_gained,
+					'variant_id', ut.variant_id
+				) ORDER BY ut.task_id)
+				FROM user_tasks ut
+				WHERE ut.user_id = uq.user_id AND ut.quest_id = uq.quest_id
+			), '[]')
+		FROM user_quests uq
+		WHERE uq.user_id = $1 AND uq.quest_id = $2
+	`, userID, questID)
+	if err != nil {
+		return err
+	}
+
+	// Начинаем новую попытку
+	_, err = tx.ExecContext(ctx, `
+		UPDATE user_quests
+		SET status = 'purchased',
+			attempt = attempt + 1,
+			xp_gained = NULL,
+			coin_gained = NULL,
+			started_at = NULL,
+			completed_at = NULL,
+			expires_at = NULL
+		WHERE user_id = $1 AND quest_id = $2
+	`, userID, questID)
+	if err != nil {
+		return err
+	}
+
+	// Сбрасываем задачи (расписание тоже, так как оно относилось к прошлой попытке)
+	_, err = tx.ExecContext(ctx, `
+		UPDATE user_tasks
+		SET status = 'not_started',
+			scheduled_start = NULL,
+			scheduled_end = NULL,
+			deadline = NULL,
+			duration = NULL,
+			updated_by_ai = FALSE,
+			is_confirmed = FALSE,
+			variant_id = NULL,
+			progress_value = 0,
+			progress_period_start = NULL,
+			completed_at = NULL,
+			xp_gained = 0,
+			coin_gained = 0
+		WHERE user_id = $1 AND quest_id = $2
+	`, userID, questID)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// GetQuestAttempts возвращает историю прошлых попыток прохождения квеста пользователем
+func (r *QuestRepository) GetQuestAttempts(ctx context.Context, userID, questID int) ([]models.QuestAttempt, error) {
+	attempts := []models.QuestAttempt{}
+	err := r.db.SelectContext(ctx, &attempts, `
+		SELECT id, user_id, quest_id, attempt, status, xp_gained, coin_gained,
+			started_at, completed_at, expires_at, tasks_snapshot, archived_at
+		FROM user_quest_attempts
+		WHERE user_id = $1 AND quest_id = $2
+		ORDER BY attempt ASC
+	`, userID, questID)
+	if err != nil {
+		return nil, err
+	}
+
+	return attempts, nil
+}
@@ -0,0 +1,104 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"BecomeOverMan/internal/models"
+
+	"github.com/jmoiron/sqlx"
+)
+
+var (
+	ErrQuestNotFound = errors.New("quest not found")
+	ErrQuestInUse    = errors.New("quest has been purchased by users and can't be deleted")
+
+	ErrQuestTaskNotFound    = errors.New("task not found in the current version of the quest")
+	ErrQuestVersionConflict = errors.New("quest has been changed concurrently, reload it and try again")
+)
+
+// GetQuestDefinition возвращает последнюю версию квеста с задачами (без данных пользователя) -
+// исходные данные для изменения квеста и его задач
+func (r *QuestRepository) GetQuestDefinition(ctx context.Context, questID int) (*models.Quest, error) {
+	var quest models.Quest
+	err := r.db.GetContext(ctx, &quest, `SELECT * FROM quests WHERE id = $1`, questID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrQuestNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := attachDefinitionTasks(ctx, r.db, &quest); err != nil {
+		return nil, err
+	}
+
+	return &quest, nil
+}
+
+// attachDefinitionTasks загружает задачи последней версии квеста вместе с вариантами
+func attachDefinitionTasks(ctx context.Context, q sqlx.QueryerContext, quest *models.Quest) error {
+	err := sqlx.SelectContext(ctx, q, &quest.Tasks, `
+		SELECT t.*, qt.task_order, qt.required_occurrences
+		FROM tasks t
+		INNER JOIN quest_tasks qt ON qt.task_id = t.id
+		WHERE qt.quest_id = $1 AND qt.version = $2
+		ORDER BY qt.task_order
+	`, quest.ID, quest.Version)
+	if err != nil {
+		return err
+	}
+
+	return attachTaskVariants(ctx, q, quest)
+}
+
+// DeleteQuest удаляет квест автора вместе с задачами всех его версий.
+// Квест, который хоть раз покупали, удалить нельзя: вместе с ним удалилась бы история прохождения
+// (и основание начисленного пользователям опыта, см. ledgerDiscrepanciesSQL).
+func (r *QuestRepository) DeleteQuest(ctx context.Context, questID, userID int) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var authorID *int
+	err = tx.GetContext(ctx, &authorID, `SELECT author_id FROM quests WHERE id = $1 FOR UPDATE`, questID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrQuestNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if authorID == nil || *authorID != userID {
+		return ErrQuestForbidden
+	}
+
+	var inUse bool
+	err = tx.GetContext(ctx, &inUse, `
+		SELECT EXISTS(SELECT 1 FROM user_quests WHERE quest_id = $1)
+			OR EXISTS(SELECT 1 FROM user_quest_attempts WHERE quest_id = $1)
+	`, questID)
+	if err != nil {
+		return err
+	}
+	if inUse {
+		return ErrQuestInUse
+	}
+
+	// Задачи принадлежат только своему квесту (см. insertQuestTasks), quest_tasks удалятся каскадно
+	_, err = tx.ExecContext(ctx, `
+		DELETE FROM tasks
+		WHERE id IN (SELECT task_id FROM quest_tasks WHERE quest_id = $1)
+	`, questID)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM quests WHERE id = $1`, questID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,52 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"BecomeOverMan/internal/models"
+
+	"github.com/lib/pq"
+)
+
+// GetQuestByExternalKey возвращает последнюю версию квеста с задачами по ключу бандла
+func (r *QuestRepository) GetQuestByExternalKey(ctx context.Context, externalKey string) (*models.Quest, error) {
+	var quest models.Quest
+	err := r.db.GetContext(ctx, &quest, `SELECT * FROM quests WHERE external_key = $1`, externalKey)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrQuestNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := attachDefinitionTasks(ctx, r.db, &quest); err != nil {
+		return nil, err
+	}
+
+	return &quest, nil
+}
+
+// GetQuestDefinitions возвращает последние версии квестов с задачами для экспорта в бандл.
+// Без ids - все системные квесты.
+func (r *QuestRepository) GetQuestDefinitions(ctx context.Context, ids []int) ([]models.Quest, error) {
+	var quests []models.Quest
+	var err error
+	if len(ids) > 0 {
+		err = r.db.SelectContext(ctx, &quests, `SELECT * FROM quests WHERE id = ANY($1) ORDER BY id`, pq.Array(ids))
+	} else {
+		err = r.db.SelectContext(ctx, &quests, `SELECT * FROM quests WHERE author_id IS NULL ORDER BY id`)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range quests {
+		if err := attachDefinitionTasks(ctx, r.db, &quests[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	return quests, nil
+}
@@ -0,0 +1,75 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"BecomeOverMan/internal/models"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// ErrQuestLocked - условия conditions_json квеста не выполнены
+type ErrQuestLocked struct {
+	Reasons []string
+}
+
+func (e *ErrQuestLocked) Error() string {
+	return fmt.Sprintf("quest is locked: %s", strings.Join(e.Reasons, "; "))
+}
+
+// loadConditionsState собирает все, что нужно для проверки conditions_json квестов.
+// Принимает как *sqlx.DB, так и *sqlx.Tx.
+func loadConditionsState(ctx context.Context, q sqlx.QueryerContext, userID int) (models.ConditionsState, error) {
+	var user models.User
+	err := sqlx.GetContext(ctx, q, &user, `
+		SELECT level, health_level, mental_health_level, intelligence_level, charisma_level, willpower_level
+		FROM users WHERE id = $1
+	`, userID)
+	if err != nil {
+		return models.ConditionsState{}, err
+	}
+
+	var completedQuestIDs []int
+	err = sqlx.SelectContext(ctx, q, &completedQuestIDs, `
+		SELECT quest_id FROM user_quests WHERE user_id = $1 AND status = 'completed'
+	`, userID)
+	if err != nil {
+		return models.ConditionsState{}, err
+	}
+
+	var achievementIDs []int
+	err = sqlx.SelectContext(ctx, q, &achievementIDs, `
+		SELECT achievement_id FROM user_achievements WHERE user_id = $1
+	`, userID)
+	if err != nil {
+		return models.ConditionsState{}, err
+	}
+
+	return models.ConditionsState{
+		Level:             user.Level,
+		Attributes:        user.AttributeLevels(),
+		CompletedQuestIDs: completedQuestIDs,
+		AchievementIDs:    achievementIDs,
+		Now:               time.Now(),
+	}, nil
+}
+
+// questLockedReasons возвращает причины, по которым квест недоступен пользователю.
+// Некорректный conditions_json тоже считается причиной блокировки.
+func questLockedReasons(quest *models.Quest, state models.ConditionsState) []string {
+	conditions, err := models.ParseQuestConditions(quest.ConditionsJson)
+	if err != nil {
+		return []string{err.Error()}
+	}
+
+	return conditions.Evaluate(state)
+}
+
+// applyQuestConditions заполняет IsLocked и LockedReasons у квеста
+func applyQuestConditions(quest *models.Quest, state models.ConditionsState) {
+	quest.LockedReasons = questLockedReasons(quest, state)
+	quest.IsLocked = len(quest.LockedReasons) > 0
+}
@@ -0,0 +1,122 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+var (
+	ErrQuestExpired = errors.New("quest time limit expired")
+)
+
+// ExpiredQuest - квест пользователя, переведенный воркером в статус failed
+type ExpiredQuest struct {
+	UserID    int       `db:"user_id"`
+	QuestID   int       `db:"quest_id"`
+	ExpiresAt time.Time `db:"expires_at"`
+}
+
+// checkQuestNotExpired проверяет, что у начатого квеста пользователя не истекло время
+func checkQuestNotExpired(tx *sqlx.Tx, ctx context.Context, userID, questID int) error {
+	var expired bool
+	err := tx.GetContext(ctx, &expired, `
+		SELECT EXISTS (
+			SELECT 1 FROM user_quests
+			WHERE user_id = $1 AND quest_id = $2 AND status = 'started'
+			AND expires_at IS NOT NULL AND expires_at <= NOW()
+		)`, userID, questID)
+	if err != nil {
+		return err
+	}
+
+	if expired {
+		return ErrQuestExpired
+	}
+
+	return nil
+}
+
+// FailExpiredQuests переводит в failed начатые квесты, у которых истек expires_at,
+// вместе с их невыполненными задачами. За один вызов обрабатывается не больше batchSize строк.
+// FOR UPDATE SKIP LOCKED гарантирует, что несколько инстансов приложения
+// не возьмут в обработку одну и ту же строку.
+func (r *QuestRepository) FailExpiredQuests(ctx context.Context, batchSize int) ([]ExpiredQuest, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var expired []ExpiredQuest
+	err = tx.SelectContext(ctx, &expired, `
+		WITH expired AS (
+			SELECT id FROM user_quests
+			WHERE status = 'started'
+			AND expires_at IS NOT NULL AND expires_at <= NOW()
+			ORDER BY expires_at
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		UPDATE user_quests uq
+		SET status = 'failed'
+		FROM expired
+		WHERE uq.id = expired.id
+		RETURNING uq.user_id, uq.quest_id, uq.expires_at
+	`, batchSize)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(expired) == 0 {
+		return nil, nil
+	}
+
+	userIDs := make([]int, len(expired))
+	questIDs := make([]int, len(expired))
+	for i, e := range expired {
+		userIDs[i] = e.UserID
+		questIDs[i] = e.QuestID
+	}
+
+	if err := failUserQuestTasks(tx, ctx, userIDs, questIDs); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return expired, nil
+}
+
+// failUserQuestTasks проваливает невыполненные задачи квестов, переведенных в failed,
+// и совместные квесты с их участием (userIDs[i] - пользователь квеста questIDs[i])
+func failUserQuestTasks(tx *sqlx.Tx, ctx context.Context, userIDs, questIDs []int) error {
+	// Проваливаем все невыполненные задачи этих квестов
+	_, err := tx.ExecContext(ctx, `
+		UPDATE user_tasks ut
+		SET status = 'failed'
+		FROM unnest($1::int[], $2::int[]) AS e(user_id, quest_id)
+		WHERE ut.user_id = e.user_id
+		  AND ut.quest_id = e.quest_id
+		  AND ut.status != 'completed'
+	`, pq.Array(userIDs), pq.Array(questIDs))
+	if err != nil {
+		return err
+	}
+
+	// Совместные квесты, где хотя бы один участник провалился, тоже считаются проваленными
+	_, err = tx.ExecContext(ctx, `
+		UPDATE shared_quests sq
+		SET status = 'failed'
+		FROM unnest($1::int[], $2::int[]) AS e(user_id, quest_id)
+		WHERE sq.quest_id = e.quest_id
+		  AND (sq.user1_id = e.user_id OR sq.user2_id = e.user_id)
+		  AND sq.status = 'active'
+	`, pq.Array(userIDs), pq.Array(questIDs))
+	return err
+}
@@ -0,0 +1,111 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"BecomeOverMan/internal/models"
+)
+
+var (
+	ErrQuestNotRefundable = errors.New("only a purchased quest that was never started can be refunded")
+	ErrQuestRefundExpired = errors.New("quest refund window has expired")
+)
+
+// RefundQuest возвращает цену купленного, но ни разу не начатого квеста, если с покупки прошло не больше window.
+// Возвращается сумма, фактически списанная при покупке (по журналу). Квест и его задачи удаляются
+// у пользователя, так что квест можно купить снова. Возвращает сумму возврата.
+func (r *QuestRepository) RefundQuest(ctx context.Context, userID, questID int, window time.Duration) (int, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	// Блокируем квест пользователя, чтобы его не начали параллельно с возвратом.
+	// После retry попытка > 1: цена квеста уже "отработана", возвращать нечего
+	var status string
+	var attempt int
+	var purchasedAt *time.Time
+	err = tx.QueryRowContext(ctx, `
+		SELECT status, attempt, purchased_at FROM user_quests
+		WHERE user_id = $1 AND quest_id = $2
+		FOR UPDATE
+	`, userID, questID).Scan(&status, &attempt, &purchasedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, ErrQuestNotFound
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	if status != "purchased" || attempt != 1 {
+		return 0, ErrQuestNotRefundable
+	}
+
+	// Ни одна задача не должна быть выполнена
+	var anyDone bool
+	err = tx.GetContext(ctx, &anyDone, `
+		SELECT EXISTS(
+			SELECT 1 FROM user_tasks
+			WHERE user_id = $1 AND quest_id = $2 AND status <> 'not_started'
+		)`, userID, questID)
+	if err != nil {
+		return 0, err
+	}
+	if anyDone {
+		return 0, ErrQuestNotRefundable
+	}
+
+	// Квесты, купленные до появления purchased_at, вернуть нельзя
+	if purchasedAt == nil || time.Since(*purchasedAt) > window {
+		return 0, ErrQuestRefundExpired
+	}
+
+	// Сумма последней покупки квеста (у бесплатного квеста записи нет)
+	var paid int
+	err = tx.GetContext(ctx, &paid, `
+		SELECT COALESCE((
+			SELECT -amount FROM user_coin_transactions
+			WHERE user_id = $1 AND reference_type = $2 AND reference_id = $3 AND transaction_type = $4
+			ORDER BY id DESC
+			LIMIT 1
+		), 0)`, userID, models.CoinRefQuest, questID, models.CoinTxSpent)
+	if err != nil {
+		return 0, err
+	}
+
+	var title string
+	if err := tx.GetContext(ctx, &title, `SELECT title FROM quests WHERE id = $1`, questID); err != nil {
+		return 0, err
+	}
+
+	_, err = tx.ExecContext(ctx, `DELETE FROM user_tasks WHERE user_id = $1 AND quest_id = $2`, userID, questID)
+	if err != nil {
+		return 0, err
+	}
+
+	_, err = tx.ExecContext(ctx, `DELETE FROM user_quests WHERE user_id = $1 AND quest_id = $2`, userID, questID)
+	if err != nil {
+		return 0, err
+	}
+
+	_, err = writeCoinTransaction(ctx, tx, coinEntry{
+		UserID:        userID,
+		Amount:        paid,
+		Type:          models.CoinTxRefund,
+		ReferenceType: models.CoinRefQuest,
+		ReferenceID:   questID,
+		Description:   "Refunded quest: " + title,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return paid, nil
+}
@@ -2,6 +2,7 @@ package repositories
 
 import (
 	"context"
+	"database/sql"
 	"errors"
 	"fmt"
 	"math"
@@ -13,6 +14,11 @@ import (
 	"github.com/lib/pq"
 )
 
+var (
+	ErrTaskLocked       = errors.New("previous tasks of the sequential quest must be completed first")
+	ErrQuestNotApproved = errors.New("only quests approved by moderation can be purchased or shared")
+)
+
 type QuestRepository struct {
 	db *sqlx.DB
 }
@@ -33,50 +39,95 @@ func (r *QuestRepository) SaveQuestToDB(quest *models.Quest, tasks []models.Task
 	err = tx.QueryRow(`
 		INSERT INTO quests (
 			title, description, category, rarity, difficulty, price, tasks_count,
-			reward_xp, reward_coin, time_limit_hours, is_sequential
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+			reward_xp, reward_coin, time_limit_hours, is_sequential, requires_confirmation,
+			window_policy, deadline_policy, reduced_reward_percent, conditions_json, bonus_json,
+			author_id, visibility, moderation_status, external_key, template_id
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12,
+			COALESCE(NULLIF($13, ''), 'allow'), COALESCE(NULLIF($14, ''), 'allow'), COALESCE(NULLIF($15, 0), 50),
+			$16, $17, $18, COALESCE(NULLIF($19, ''), 'private'),
+			-- квесты пользователей начинают с черновика, системным квестам проверка не нужна
+			CASE WHEN $18::int IS NULL THEN 'approved' ELSE 'draft' END,
+			$20, $21
+		)
 		RETURNING id
 	`,
 		quest.Title, quest.Description, quest.Category, quest.Rarity,
 		quest.Difficulty, quest.Price, quest.TasksCount, quest.RewardXP,
-		quest.RewardCoin, quest.TimeLimitHours, true,
+		quest.RewardCoin, quest.TimeLimitHours, quest.IsSequential, quest.RequiresConfirmation,
+		quest.WindowPolicy, quest.DeadlinePolicy, quest.ReducedRewardPercent,
+		quest.ConditionsJson, quest.BonusJson,
+		quest.AuthorID, quest.Visibility, quest.ExternalKey, quest.TemplateID,
 	).Scan(&questID)
 	if err != nil {
 		return 0, err
 	}
 
+	// Без явного ключа квест получает ключ по id, чтобы его можно было экспортировать
+	if quest.ExternalKey == nil {
+		if _, err := tx.Exec(`UPDATE quests SET external_key = 'quest-' || id WHERE id = $1`, questID); err != nil {
+			return 0, err
+		}
+	}
+
 	// Вставляем задачи
+	if err := insertQuestTasks(tx, questID, 1, tasks); err != nil {
+		return 0, err
+	}
+
+	// Публикуем первую версию квеста
+	if _, err := tx.Exec(snapshotQuestVersionSQL, questID); err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	return questID, nil
+}
+
+// insertQuestTasks создает задачи версии version квеста. Задачи не изменяются после создания,
+// поэтому новая версия квеста всегда получает новые строки tasks.
+func insertQuestTasks(tx *sql.Tx, questID, version int, tasks []models.Task) error {
 	for _, task := range tasks {
 		var taskID int
-		err = tx.QueryRow(`
+		err := tx.QueryRow(`
 			INSERT INTO tasks (
 				title, description, difficulty, rarity, category, 
-				base_xp_reward, base_coin_reward
-			) VALUES ($1, $2, $3, $4, $5, $6, $7)
+				base_xp_reward, base_coin_reward, type, cooldown_hours, proof_type,
+				target_value, unit
+			) VALUES (
+				$1, $2, $3, $4, $5, $6, $7,
+				COALESCE(NULLIF($8, ''), 'special')::task_type, $9, COALESCE(NULLIF($10, ''), 'none'),
+				$11, $12
+			)
 			RETURNING id
 		`,
 			task.Title, task.Description, task.Difficulty, task.Rarity,
 			task.Category, task.BaseXpReward, task.BaseCoinReward,
+			task.Type, task.CooldownHours, task.ProofType,
+			task.TargetValue, task.Unit,
 		).Scan(&taskID)
 		if err != nil {
-			return 0, err
+			return err
 		}
 
 		// Связываем задачу с квестом
 		_, err = tx.Exec(`
-			INSERT INTO quest_tasks (quest_id, task_id, task_order)
-			VALUES ($1, $2, $3)
-		`, questID, taskID, task.TaskOrder)
+			INSERT INTO quest_tasks (quest_id, task_id, task_order, required_occurrences, version)
+			VALUES ($1, $2, $3, GREATEST($4, 1), $5)
+		`, questID, taskID, task.TaskOrder, task.RequiredOccurrences, version)
 		if err != nil {
-			return 0, err
+			return err
 		}
-	}
 
-	if err := tx.Commit(); err != nil {
-		return 0, err
+		if err := insertTaskVariants(tx, taskID, task.Variants); err != nil {
+			return err
+		}
 	}
 
-	return questID, nil
+	return nil
 }
 
 func (r *QuestRepository) SetOrUpdateScheduleTasks(ctx context.Context, userID int, tasks []models.Task) error {
@@ -128,6 +179,7 @@ const (
 		SELECT 
 			t.*,
 			qt.task_order,
+			qt.required_occurrences,
 			ut.status,
 			ut.scheduled_start,
 			ut.scheduled_end,
@@ -137,12 +189,18 @@ const (
 			ut.is_confirmed,
 			ut.completed_at,
 			ut.xp_gained,
-			ut.coin_gained
+			ut.coin_gained,
+			ut.variant_id,
+			` + taskOccurrencesColumns + `,
+			` + taskProgressColumns + `
 		FROM tasks t
 		INNER JOIN quest_tasks qt ON t.id = qt.task_id
 		LEFT JOIN user_tasks ut 
 			ON t.id = ut.task_id AND ut.user_id = $2
+		LEFT JOIN user_quests uq
+			ON uq.quest_id = qt.quest_id AND uq.user_id = $2
 		WHERE qt.quest_id = $1
+		AND qt.version = COALESCE(uq.quest_version, (SELECT version FROM quests WHERE id = $1))
 		ORDER BY qt.task_order ASC
 	`
 )
@@ -150,15 +208,29 @@ const (
 // GetQuestDetails возвращает детали квеста со всеми задачами.
 // Если userID существует ??, возвращает доп. информацию о статусе, дедлайнах и наградах пользователя.
 func (r *QuestRepository) GetQuestDetails(ctx context.Context, questID int, userID int) (*models.Quest, error) {
+	// Чужие приватные квесты (и квесты не друзей с видимостью friends) для пользователя не существуют
+	visible, err := isQuestVisible(ctx, r.db, questID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !visible {
+		return nil, ErrQuestNotFound
+	}
+
 	// Получаем основную информацию о квесте
 	var quest models.Quest
-	err := r.db.GetContext(ctx, &quest, `
+	err = r.db.GetContext(ctx, &quest, `
         SELECT * FROM quests WHERE id = $1
     `, questID)
 	if err != nil {
 		return nil, err
 	}
 
+	// Если пользователь купил квест - показываем его версию
+	if err := pinQuestVersion(ctx, r.db, &quest, userID); err != nil {
+		return nil, err
+	}
+
 	// Получаем все задачи этого квеста
 	var tasks []models.Task
 	err = r.db.SelectContext(ctx, &tasks, queryGetQuestDetails, questID, userID)
@@ -167,10 +239,40 @@ func (r *QuestRepository) GetQuestDetails(ctx context.Context, questID int, user
 	}
 
 	quest.Tasks = tasks
+	setUnlockedTask(&quest)
+
+	if err := attachTaskVariants(ctx, r.db, &quest); err != nil {
+		return nil, err
+	}
+
+	if err := attachTaskSubmissions(ctx, r.db, &quest, userID); err != nil {
+		return nil, err
+	}
 
 	return &quest, nil
 }
 
+// setUnlockedTask для последовательного квеста отмечает первую по task_order невыполненную задачу пользователя.
+// Задачи должны быть уже отсортированы по task_order (как в queryGetQuestDetails).
+func setUnlockedTask(quest *models.Quest) {
+	quest.UnlockedTaskID = nil
+	if !quest.IsSequential {
+		return
+	}
+
+	for _, task := range quest.Tasks {
+		if task.Status == nil {
+			// квест не куплен пользователем
+			return
+		}
+		if *task.Status != "completed" {
+			taskID := task.ID
+			quest.UnlockedTaskID = &taskID
+			return
+		}
+	}
+}
+
 func (r *QuestRepository) GetMyAllQuestsWithDetails(ctx context.Context, userID int) ([]models.Quest, error) {
 	var quests []models.Quest
 	err := r.db.SelectContext(ctx, &quests, `
@@ -183,6 +285,10 @@ func (r *QuestRepository) GetMyAllQuestsWithDetails(ctx context.Context, userID
 	}
 
 	for i := range quests {
+		if err := pinQuestVersion(ctx, r.db, &quests[i], userID); err != nil {
+			return nil, err
+		}
+
 		var tasks []models.Task
 		err = r.db.SelectContext(ctx, &tasks, queryGetQuestDetails, quests[i].ID, userID)
 		if err != nil {
@@ -190,6 +296,15 @@ func (r *QuestRepository) GetMyAllQuestsWithDetails(ctx context.Context, userID
 		}
 
 		quests[i].Tasks = tasks
+		setUnlockedTask(&quests[i])
+
+		if err := attachTaskVariants(ctx, r.db, &quests[i]); err != nil {
+			return nil, err
+		}
+
+		if err := attachTaskSubmissions(ctx, r.db, &quests[i], userID); err != nil {
+			return nil, err
+		}
 	}
 
 	return quests, nil
@@ -197,20 +312,20 @@ func (r *QuestRepository) GetMyAllQuestsWithDetails(ctx context.Context, userID
 
 // Для Search (Recommendation Service)
 // сделать версии для своих квестов, для магазина, для доступных к покупке
-func (r *QuestRepository) SearchQuestsWithDetailsByIDs(ctx context.Context, ids []int) ([]models.Quest, error) {
+func (r *QuestRepository) SearchQuestsWithDetailsByIDs(ctx context.Context, ids []int, userID int) ([]models.Quest, error) {
 	if len(ids) == 0 {
 		return []models.Quest{}, nil
 	}
 
 	query := `
-		SELECT * FROM quests
-		WHERE id = ANY($1)
-		ORDER BY array_position($1, id)
+		SELECT q.* FROM quests q
+		WHERE q.id = ANY($1) AND ` + questVisibleSQL("$2") + `
+		ORDER BY array_position($1, q.id)
 	` // ORDER BY array_position($1, id) нужен чтобы вернулось в порядке релевантности
 
 	var quests []models.Quest
 
-	err := r.db.SelectContext(ctx, &quests, query, pq.Array(ids))
+	err := r.db.SelectContext(ctx, &quests, query, pq.Array(ids), userID)
 	if err != nil {
 		return nil, fmt.Errorf("ошибка получения квестов из БД с указанными ids: %w", err)
 	}
@@ -224,9 +339,9 @@ func (r *QuestRepository) SearchQuestsWithDetailsByIDs(ctx context.Context, ids
 				qt.task_order
 			FROM tasks t
 			INNER JOIN quest_tasks qt ON t.id = qt.task_id
-			WHERE qt.quest_id = $1
+			WHERE qt.quest_id = $1 AND qt.version = $2
 			ORDER BY qt.task_order ASC
-			`, quests[i].ID)
+			`, quests[i].ID, quests[i].Version)
 		if err != nil {
 			return nil, err
 		}
@@ -253,7 +368,7 @@ func (r *QuestRepository) GetAvailableQuests(ctx context.Context, userID int) ([
 		WHERE q.difficulty <= $1 + 1 AND q.price <= $2 AND NOT EXISTS (
 			SELECT 1 FROM user_quests uq
 			WHERE uq.quest_id = q.id AND uq.user_id = $3
-		)
+		) AND ` + questVisibleSQL("$3") + ` AND ` + eventQuestOpenSQL + `
 	`
 
 	err = r.db.SelectContext(ctx, &quests, query, user.Level, user.CoinBalance, userID)
@@ -261,10 +376,27 @@ func (r *QuestRepository) GetAvailableQuests(ctx context.Context, userID int) ([
 		return nil, err
 	}
 
-	return quests, nil
+	// Оставляем только квесты, у которых выполнены conditions_json
+	state, err := loadConditionsState(ctx, r.db, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	available := make([]models.Quest, 0, len(quests))
+	for i := range quests {
+		applyQuestConditions(&quests[i], state)
+		if !quests[i].IsLocked {
+			available = append(available, quests[i])
+		}
+	}
+
+	return available, nil
 }
 
-func (r *QuestRepository) GetQuestShop(ctx context.Context, userID int) ([]models.Quest, error) {
+// GetQuestShop возвращает все не купленные пользователем квесты.
+// Квесты с невыполненными conditions_json тоже возвращаются, но с is_locked и причинами блокировки.
+// sortBy = models.QuestSortRating сортирует квесты по средней оценке.
+func (r *QuestRepository) GetQuestShop(ctx context.Context, userID int, sortBy string) ([]models.Quest, error) {
 	var quests []models.Quest
 
 	query := `
@@ -272,14 +404,26 @@ func (r *QuestRepository) GetQuestShop(ctx context.Context, userID int) ([]model
 	WHERE NOT EXISTS (
 		SELECT 1 FROM user_quests uq
 		WHERE uq.quest_id = q.id AND uq.user_id = $1
-	)`
-	// + TODO: conditions_json нужно проверить
+	) AND ` + questVisibleSQL("$1") + ` AND ` + eventQuestOpenSQL
+
+	if sortBy == models.QuestSortRating {
+		query += ` ORDER BY q.average_rating DESC NULLS LAST, q.rating_count DESC, q.id`
+	}
 
 	// Получаем все квесты, что у нас не куплены и не были пройдены
 	if err := r.db.SelectContext(ctx, &quests, query, userID); err != nil {
 		return nil, err
 	}
 
+	state, err := loadConditionsState(ctx, r.db, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range quests {
+		applyQuestConditions(&quests[i], state)
+	}
+
 	return quests, nil
 }
 
@@ -337,11 +481,38 @@ func (r *QuestRepository) PurchaseQuest(ctx context.Context, userID, questID int
 
 	// Проверяем, что квест существует
 	var quest models.Quest
-	err = tx.GetContext(ctx, &quest, "SELECT * FROM quests WHERE id = $1", questID)
+	// FOR SHARE - чтобы версия квеста не сменилась до конца покупки
+	err = tx.GetContext(ctx, &quest, "SELECT * FROM quests WHERE id = $1 FOR SHARE", questID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrQuestNotFound
+	}
 	if err != nil {
 		return err
 	}
 
+	// Купить можно только видимый пользователю квест
+	visible, err := isQuestVisible(ctx, tx, questID, userID)
+	if err != nil {
+		return err
+	}
+	if !visible {
+		return ErrQuestNotFound
+	}
+
+	// Автор видит свой черновик, но купить (и получить за него награду) можно только одобренный квест
+	if quest.ModerationStatus != models.QuestModerationApproved {
+		return ErrQuestNotApproved
+	}
+
+	// Квест события продается только во время события
+	open, err := isEventQuestOpen(ctx, tx, questID)
+	if err != nil {
+		return err
+	}
+	if !open {
+		return ErrEventNotActive
+	}
+
 	// Проверяем что такой квест у нас не куплен и не был пройден
 	var alreadyUsed bool
 	err = tx.GetContext(ctx, &alreadyUsed, `
@@ -358,31 +529,35 @@ func (r *QuestRepository) PurchaseQuest(ctx context.Context, userID, questID int
 		return errors.New("quest already purchased or completed")
 	}
 
-	// Проверяем баланс пользователя
-	var balance int
-	err = tx.GetContext(ctx, &balance, "SELECT coin_balance FROM users WHERE id = $1", userID)
+	// Проверяем условия доступности квеста
+	state, err := loadConditionsState(ctx, tx, userID)
 	if err != nil {
 		return err
 	}
 
-	if balance < quest.Price {
-		return errors.New("not enough currency")
+	if reasons := questLockedReasons(&quest, state); len(reasons) > 0 {
+		return &ErrQuestLocked{Reasons: reasons}
 	}
 
-	// Списываем валюту
-	_, err = tx.ExecContext(ctx,
-		"UPDATE users SET coin_balance = coin_balance - $1 WHERE id = $2",
-		quest.Price, userID)
+	// Списываем валюту (при нехватке монет - ErrNotEnoughCoins)
+	_, err = writeCoinTransaction(ctx, tx, coinEntry{
+		UserID:        userID,
+		Amount:        -quest.Price,
+		Type:          models.CoinTxSpent,
+		ReferenceType: models.CoinRefQuest,
+		ReferenceID:   quest.ID,
+		Description:   "Purchased quest: " + quest.Title,
+	})
 	if err != nil {
 		return err
 	}
 
-	// Добавляем квест пользователю
+	// Добавляем квест пользователю (закрепляем последнюю версию)
 	_, err = tx.ExecContext(ctx, `
         INSERT INTO user_quests 
-        (user_id, quest_id, status, started_at, expires_at)
-        VALUES ($1, $2, 'purchased', NULL, NULL)`,
-		userID, questID)
+        (user_id, quest_id, status, started_at, expires_at, quest_version)
+        VALUES ($1, $2, 'purchased', NULL, NULL, $3)`,
+		userID, questID, quest.Version)
 	if err != nil {
 		return err
 	}
@@ -391,19 +566,9 @@ func (r *QuestRepository) PurchaseQuest(ctx context.Context, userID, questID int
 		INSERT INTO user_tasks (user_id, task_id, quest_id, status)
 		SELECT $1, qt.task_id, qt.quest_id, 'not_started'
 		FROM quest_tasks qt
-		WHERE qt.quest_id = $2
+		WHERE qt.quest_id = $2 AND qt.version = $3
 		ORDER BY qt.task_order
-	`, userID, questID)
-	if err != nil {
-		return err
-	}
-
-	// Записываем транзакцию
-	_, err = tx.ExecContext(ctx, `
-        INSERT INTO user_coin_transactions 
-        (user_id, amount, transaction_type, reference_type, reference_id, description)
-        VALUES ($1, $2, 'spent', 'quest', $3, 'Purchased quest: ' || $4)`,
-		userID, -quest.Price, quest.ID, quest.Title)
+	`, userID, questID, quest.Version)
 	if err != nil {
 		return err
 	}
@@ -422,12 +587,18 @@ func (r *QuestRepository) StartQuest(ctx context.Context, userID, questID int) e
 	// Устанавливаем время начала и завершения
 	var timeLimitHours int
 	err = tx.GetContext(ctx, &timeLimitHours,
-		"SELECT time_limit_hours FROM quests WHERE id = $1", questID)
+		"SELECT q.time_limit_hours FROM "+pinnedQuestSQL+" WHERE uq.user_id = $1 AND uq.quest_id = $2",
+		userID, questID)
 	if err != nil {
 		return err
 	}
 
-	expiresAt := time.Now().Add(time.Duration(timeLimitHours) * time.Hour)
+	// time_limit_hours = 0 означает, что ограничения по времени нет
+	var expiresAt *time.Time
+	if timeLimitHours > 0 {
+		t := time.Now().Add(time.Duration(timeLimitHours) * time.Hour)
+		expiresAt = &t
+	}
 
 	_, err = tx.ExecContext(ctx, `
         UPDATE user_quests 
@@ -474,39 +645,59 @@ func calculateLevel(xp int) int {
 	return level
 }
 
-// addXPAndCoinsWithLevelUp начисляет опыт и монеты пользователю, автоматически повышая уровень
-func (r *QuestRepository) addXPAndCoinsWithLevelUp(tx *sqlx.Tx, ctx context.Context, userID, xpAmount, coinAmount int) error {
+// addXPAndCoinsWithLevelUp начисляет опыт и монеты пользователю, автоматически повышая уровень.
+// К награде применяются действующие на пользователя множители (см. loadActiveEffects),
+// category - категория награды для множителей монет по категории. Монеты проходят через журнал
+// (writeCoinTransaction) с типом и ссылкой из coinRef.
+// Возвращает фактически начисленные опыт и монеты.
+func (r *QuestRepository) addXPAndCoinsWithLevelUp(tx *sqlx.Tx, ctx context.Context, userID int, category string, xpAmount, coinAmount int, coinRef coinEntry) (int, int, error) {
+	effects, err := loadActiveEffects(ctx, tx, userID)
+	if err != nil {
+		return 0, 0, err
+	}
+	xpAmount, coinAmount = models.ApplyRewardMultipliers(effects, category, xpAmount, coinAmount)
+
+	if err := addXPWithLevelUp(tx, ctx, userID, xpAmount); err != nil {
+		return 0, 0, err
+	}
+
+	coinRef.UserID = userID
+	coinRef.Amount = coinAmount
+	if _, err := writeCoinTransaction(ctx, tx, coinRef); err != nil {
+		return 0, 0, err
+	}
+
+	return xpAmount, coinAmount, nil
+}
+
+// addXPWithLevelUp начисляет опыт (отрицательный - списывает, но не ниже 0) и пересчитывает уровень
+func addXPWithLevelUp(tx *sqlx.Tx, ctx context.Context, userID, xpAmount int) error {
 	// Получаем текущий опыт пользователя
 	var currentXP int
-	err := tx.GetContext(ctx, &currentXP, "SELECT xp_points FROM users WHERE id = $1", userID)
+	err := tx.GetContext(ctx, &currentXP, "SELECT xp_points FROM users WHERE id = $1 FOR UPDATE", userID)
 	if err != nil {
 		return err
 	}
 
 	// Вычисляем новый опыт и уровень
-	newXP := currentXP + xpAmount
-	newLevel := calculateLevel(newXP)
+	newXP := max(currentXP+xpAmount, 0)
 
-	// Начисляем опыт, монеты и обновляем уровень
 	_, err = tx.ExecContext(ctx, `
 		UPDATE users 
-		SET xp_points = xp_points + $1,
-			coin_balance = coin_balance + $2,
-			level = $3
-		WHERE id = $4`,
-		xpAmount, coinAmount, newLevel, userID)
-	if err != nil {
-		return err
-	}
+		SET xp_points = $1,
+			level = $2
+		WHERE id = $3`,
+		newXP, calculateLevel(newXP), userID)
 
-	return nil
+	return err
 }
 
-// CompleteTask отмечает выполнение задачи
-func (r *QuestRepository) CompleteTask(ctx context.Context, userID, questID, taskID int) error {
+// CompleteTask отмечает выполнение задачи и возвращает ее новый статус:
+// completed или pending_confirmation, если квест требует подтверждения другом
+func (r *QuestRepository) CompleteTask(ctx context.Context, userID, questID, taskID int) (string, error) {
 	tx, err := r.db.BeginTxx(ctx, nil)
 	if err != nil {
-		return err
+		return "", err
 	}
 	defer tx.Rollback()
 
@@ -518,35 +709,136 @@ func (r *QuestRepository) CompleteTask(ctx context.Context, userID, questID, tas
 		) AND EXISTS (
 			SELECT 1 FROM quest_tasks WHERE quest_id = $2 AND task_id = $3
 		) AND EXISTS (
-			SELECT 1 FROM user_tasks WHERE user_id = $1 AND quest_id = $2 AND task_id = $3 AND status = 'active'
+			SELECT 1 FROM user_tasks WHERE user_id = $1 AND quest_id = $2 AND task_id = $3 AND status IN ('active', 'missed')
 		)
 		`, userID, questID, taskID,
 	)
 	if err != nil {
-		return err
+		return "", err
 	}
 
 	if !exists {
-		return errors.New("quest or task not found or already completed")
+		return "", errors.New("quest or task not found or already completed")
 	}
 
+	if err := checkQuestNotExpired(tx, ctx, userID, questID); err != nil {
+		return "", err
+	}
+
+	// Для последовательного квеста все задачи с меньшим task_order должны быть выполнены
+	hasLockingTasks, err := hasSequentialOrderConflict(tx, ctx, userID, questID, taskID, true)
+	if err != nil {
+		return "", err
+	}
+
+	if hasLockingTasks {
+		return "", ErrTaskLocked
+	}
+
+	// Количественную задачу можно выполнить только по достижении цели
+	if err := checkTaskTargetReached(tx, ctx, userID, questID, taskID); err != nil {
+		return "", err
+	}
+
+	// Получаем тип задачи и режим подтверждения квеста
+	var taskType string
+	var cooldownHours int
+	var requiresConfirmation bool
+	err = tx.QueryRowContext(ctx, `
+		SELECT t.type, t.cooldown_hours, COALESCE(q.requires_confirmation, FALSE)
+		FROM tasks t, `+pinnedQuestSQL+`
+		WHERE t.id = $1 AND uq.user_id = $3 AND uq.quest_id = $2
+	`, taskID, questID, userID).Scan(&taskType, &cooldownHours, &requiresConfirmation)
+	if err != nil {
+		return "", err
+	}
+
+	// Если у задачи есть варианты, один из них должен быть выбран
+	if _, _, err := taskRewardForUser(tx, ctx, userID, questID, taskID, 0, 0); err != nil {
+		return "", err
+	}
+
+	// Повторяющиеся задачи выполняются отдельными вхождениями за каждый период,
+	// для разовых учитываем расписание и дедлайн по политикам квеста
+	var occurrenceID *int
+	rewardPercent := 100
+	if models.IsRecurringTaskType(taskType) {
+		id, err := createTaskOccurrence(tx, ctx, userID, questID, taskID, cooldownHours)
+		if err != nil {
+			return "", err
+		}
+		occurrenceID = &id
+	} else {
+		rewardPercent, err = taskRewardPercent(tx, ctx, userID, questID, taskID)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	// Засчитываем доказательство выполнения, если задача его требует
+	if err := acceptTaskSubmissions(tx, ctx, userID, questID, taskID, occurrenceID); err != nil {
+		return "", err
+	}
+
+	// В режиме подтверждения награда начисляется только после одобрения друга
+	if requiresConfirmation {
+		if err := requestTaskConfirmation(tx, ctx, userID, questID, taskID, occurrenceID, rewardPercent); err != nil {
+			return "", err
+		}
+		return "pending_confirmation", tx.Commit()
+	}
+
+	if err := r.grantTaskReward(tx, ctx, userID, questID, taskID, occurrenceID, rewardPercent); err != nil {
+		return "", err
+	}
+
+	return "completed", tx.Commit()
+}
+
+// grantTaskReward начисляет награду за выполненную задачу (с учетом выбранного варианта)
+// и отмечает ее выполненной. Для повторяющихся задач occurrenceID - выполнение за текущий период.
+// rewardPercent уменьшает награду за выполнение вне расписания (см. taskRewardPercent).
+func (r *QuestRepository) grantTaskReward(tx *sqlx.Tx, ctx context.Context, userID, questID, taskID int, occurrenceID *int, rewardPercent int) error {
 	// Получаем награду за задачу
 	var baseXpReward, baseCoinReward int
-	err = tx.QueryRowContext(ctx, `
-		SELECT base_xp_reward, base_coin_reward 
+	var category, title string
+	err := tx.QueryRowContext(ctx, `
+		SELECT base_xp_reward, base_coin_reward, category, title
 		FROM tasks 
 		WHERE id = $1
-	`, taskID).Scan(&baseXpReward, &baseCoinReward)
+	`, taskID).Scan(&baseXpReward, &baseCoinReward, &category, &title)
 	if err != nil {
 		return err
 	}
 
+	// Если у задачи есть варианты, награда берется из выбранного варианта
+	baseXpReward, baseCoinReward, err = taskRewardForUser(tx, ctx, userID, questID, taskID, baseXpReward, baseCoinReward)
+	if err != nil {
+		return err
+	}
+	baseXpReward = baseXpReward * rewardPercent / 100
+	baseCoinReward = baseCoinReward * rewardPercent / 100
+
 	// Начисляем награду пользователю сразу
-	err = r.addXPAndCoinsWithLevelUp(tx, ctx, userID, baseXpReward, baseCoinReward)
+	xpGained, coinGained, err := r.addXPAndCoinsWithLevelUp(tx, ctx, userID, category, baseXpReward, baseCoinReward, coinEntry{
+		Type:          models.CoinTxEarned,
+		ReferenceType: models.CoinRefTask,
+		ReferenceID:   taskID,
+		Description:   "Completed task: " + title,
+	})
 	if err != nil {
 		return err
 	}
 
+	// Выполнение задачи засчитывает день в серию
+	if err := updateDailyStreak(tx, ctx, userID); err != nil {
+		return err
+	}
+
+	if occurrenceID != nil {
+		return completeTaskOccurrence(tx, ctx, userID, questID, taskID, *occurrenceID, xpGained, coinGained)
+	}
+
 	// обновляем статус задачи, сохраняем награду в user_tasks
 	_, err = tx.ExecContext(ctx, `
         UPDATE user_tasks ut
@@ -559,14 +851,11 @@ func (r *QuestRepository) CompleteTask(ctx context.Context, userID, questID, tas
 		WHERE ut.user_id = $1
           AND ut.quest_id = $2
           AND ut.task_id = $3
-          AND ut.status = 'active'
+          AND ut.status IN ('active', 'missed', 'pending_confirmation')
           AND t.id = ut.task_id
-		`, userID, questID, taskID, baseXpReward, baseCoinReward)
-	if err != nil {
-		return err
-	}
+		`, userID, questID, taskID, xpGained, coinGained)
 
-	return tx.Commit()
+	return err
 }
 
 // ----------------------------------------------------
@@ -602,6 +891,9 @@ func (r *QuestRepository) CompleteQuest(ctx context.Context, userID, questID int
 	if uqStatus != "started" {
 		return errors.New("quest is not in started state")
 	}
+	if err := checkQuestNotExpired(tx, ctx, userID, questID); err != nil {
+		return err
+	}
 	// --- конец проверки ---
 
 	// Проверяем: есть ли хоть одна невыполненная задача
@@ -662,19 +954,42 @@ func (r *QuestRepository) CompleteQuest(ctx context.Context, userID, questID int
 
 // completeQuestForUsers - упрощенная версия (если сложно с динамическими IN clause)
 func (r *QuestRepository) completeQuestForUsers(tx *sqlx.Tx, ctx context.Context, userIDs []int, questID int) error {
-	// Получаем награду за квест
-	var rewardXP, rewardCoin int
-	err := tx.QueryRowContext(ctx, `
-        SELECT reward_xp, reward_coin FROM quests WHERE id = $1`, questID).
-		Scan(&rewardXP, &rewardCoin)
-	if err != nil {
-		return err
-	}
-
 	// Для каждого пользователя выполняем операции
 	for _, userID := range userIDs {
+		// Получаем награду за квест (по версии, которую проходил пользователь)
+		var xp, coins int
+		var category, title string
+		var startedAt *time.Time
+		err := tx.QueryRowContext(ctx, `
+			SELECT q.reward_xp, q.reward_coin, q.category, q.title, uq.started_at
+			FROM `+pinnedQuestSQL+`
+			WHERE uq.user_id = $1 AND uq.quest_id = $2`, userID, questID).
+			Scan(&xp, &coins, &category, &title, &startedAt)
+		if err != nil {
+			return err
+		}
+
+		bonuses, err := questBonusEffects(ctx, tx, userID, questID)
+		if err != nil {
+			return err
+		}
+
+		// Доп. награда за быстрое прохождение
+		for _, b := range bonuses {
+			if b.Type == models.EffectEarlyCompletion && startedAt != nil &&
+				time.Since(*startedAt) <= time.Duration(b.WithinHours)*time.Hour {
+				xp += b.XP
+				coins += b.Coins
+			}
+		}
+
 		// Начисляем награду с автоматическим повышением уровня
-		err = r.addXPAndCoinsWithLevelUp(tx, ctx, userID, rewardXP, rewardCoin)
+		xpGained, coinGained, err := r.addXPAndCoinsWithLevelUp(tx, ctx, userID, category, xp, coins, coinEntry{
+			Type:          models.CoinTxEarned,
+			ReferenceType: models.CoinRefQuest,
+			ReferenceID:   questID,
+			Description:   "Completed quest: " + title,
+		})
 		if err != nil {
 			return err
 		}
@@ -685,18 +1000,26 @@ func (r *QuestRepository) completeQuestForUsers(tx *sqlx.Tx, ctx context.Context
             SET status = 'completed', completed_at = NOW(),
                 xp_gained = $1, coin_gained = $2
             WHERE user_id = $3 AND quest_id = $4`,
-			rewardXP, rewardCoin, userID, questID)
+			xpGained, coinGained, userID, questID)
 		if err != nil {
 			return err
 		}
 
+		// Выдаем длящиеся эффекты из bonus_json квеста (действуют уже на следующие награды)
+		if err := grantBonusEffects(tx, ctx, userID, bonuses, "quest", questID); err != nil {
+			return err
+		}
+
+		// Очки события, если квест завершен во время своего события
+		if err := awardEventPoints(tx, ctx, userID, questID); err != nil {
+			return err
+		}
+
 		// Подтверждаем задачи
 		_, err = tx.ExecContext(ctx, `
             UPDATE user_tasks 
             SET is_confirmed = true
-            WHERE user_id = $1 AND task_id IN (
-                SELECT task_id FROM quest_tasks WHERE quest_id = $2
-            )`,
+            WHERE user_id = $1 AND quest_id = $2`,
 			userID, questID)
 		if err != nil {
 			return err
@@ -0,0 +1,159 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"BecomeOverMan/internal/models"
+)
+
+var (
+	ErrQuestNotCompleted   = errors.New("only users who completed the quest can review it")
+	ErrQuestReviewNotFound = errors.New("quest review not found")
+)
+
+// UpsertQuestReview сохраняет оценку и отзыв пользователя, завершившего квест.
+// Повторный отзыв заменяет предыдущий. Агрегаты оценок квеста обновляются в той же транзакции.
+func (r *QuestRepository) UpsertQuestReview(ctx context.Context, userID, questID, rating int, review *string) (*models.QuestReview, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// FOR UPDATE - чтобы параллельные отзывы одного пользователя не испортили агрегаты
+	var userQuest struct {
+		Status       string `db:"status"`
+		QuestVersion int    `db:"quest_version"`
+	}
+	err = tx.GetContext(ctx, &userQuest, `
+		SELECT status, quest_version FROM user_quests
+		WHERE user_id = $1 AND quest_id = $2
+		FOR UPDATE
+	`, userID, questID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrQuestNotCompleted
+	}
+	if err != nil {
+		return nil, err
+	}
+	if userQuest.Status != "completed" {
+		return nil, ErrQuestNotCompleted
+	}
+
+	var oldRating int
+	err = tx.GetContext(ctx, &oldRating, `
+		SELECT rating FROM quest_reviews WHERE user_id = $1 AND quest_id = $2
+	`, userID, questID)
+	isNew := errors.Is(err, sql.ErrNoRows)
+	if err != nil && !isNew {
+		return nil, err
+	}
+
+	var saved models.QuestReview
+	err = tx.GetContext(ctx, &saved, `
+		INSERT INTO quest_reviews (user_id, quest_id, quest_version, rating, review)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (user_id, quest_id) DO UPDATE SET
+			quest_version = EXCLUDED.quest_version,
+			rating = EXCLUDED.rating,
+			review = EXCLUDED.review,
+			updated_at = NOW()
+		RETURNING id, user_id, quest_id, quest_version, rating, review, created_at, updated_at
+	`, userID, questID, userQuest.QuestVersion, rating, review)
+	if err != nil {
+		return nil, err
+	}
+
+	if isNew {
+		_, err = tx.ExecContext(ctx, `
+			UPDATE quests SET rating_sum = rating_sum + $2, rating_count = rating_count + 1
+			WHERE id = $1
+		`, questID, rating)
+	} else {
+		_, err = tx.ExecContext(ctx, `
+			UPDATE quests SET rating_sum = rating_sum + $2
+			WHERE id = $1
+		`, questID, rating-oldRating)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return &saved, nil
+}
+
+// DeleteQuestReview удаляет отзыв пользователя и убирает его оценку из агрегатов квеста
+func (r *QuestRepository) DeleteQuestReview(ctx context.Context, userID, questID int) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Та же блокировка, что и в UpsertQuestReview, чтобы удаление не гонялось с обновлением отзыва
+	var locked int
+	err = tx.GetContext(ctx, &locked, `
+		SELECT 1 FROM user_quests
+		WHERE user_id = $1 AND quest_id = $2
+		FOR UPDATE
+	`, userID, questID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrQuestReviewNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	var rating int
+	err = tx.GetContext(ctx, &rating, `
+		DELETE FROM quest_reviews WHERE user_id = $1 AND quest_id = $2
+		RETURNING rating
+	`, userID, questID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrQuestReviewNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE quests SET rating_sum = rating_sum - $2, rating_count = rating_count - 1
+		WHERE id = $1
+	`, questID, rating)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// GetQuestReviews возвращает отзывы о видимом пользователю квесте (сначала новые)
+func (r *QuestRepository) GetQuestReviews(ctx context.Context, questID, userID int) ([]models.QuestReview, error) {
+	visible, err := isQuestVisible(ctx, r.db, questID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !visible {
+		return nil, ErrQuestNotFound
+	}
+
+	reviews := []models.QuestReview{}
+	err = r.db.SelectContext(ctx, &reviews, `
+		SELECT qr.*, u.username
+		FROM quest_reviews qr
+		INNER JOIN users u ON u.id = qr.user_id
+		WHERE qr.quest_id = $1
+		ORDER BY qr.updated_at DESC, qr.id DESC
+	`, questID)
+	if err != nil {
+		return nil, err
+	}
+
+	return reviews, nil
+}
@@ -0,0 +1,68 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"BecomeOverMan/internal/models"
+)
+
+var ErrQuestTemplateNotFound = errors.New("quest template not found")
+
+// CreateQuestTemplate сохраняет шаблон квеста (параметры и квест уже закодированы в JSON)
+func (r *QuestRepository) CreateQuestTemplate(ctx context.Context, tmpl *models.QuestTemplate) error {
+	return r.db.GetContext(ctx, tmpl, `
+		INSERT INTO quest_templates (title, description, parameters_json, quest_json, created_by)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING *
+	`, tmpl.Title, tmpl.Description, tmpl.ParametersJson, tmpl.QuestJson, tmpl.CreatedBy)
+}
+
+// GetQuestTemplates возвращает все шаблоны квестов (сначала новые)
+func (r *QuestRepository) GetQuestTemplates(ctx context.Context) ([]models.QuestTemplate, error) {
+	templates := []models.QuestTemplate{}
+	err := r.db.SelectContext(ctx, &templates, `SELECT * FROM quest_templates ORDER BY created_at DESC, id DESC`)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range templates {
+		if err := templates[i].Decode(); err != nil {
+			return nil, err
+		}
+	}
+
+	return templates, nil
+}
+
+func (r *QuestRepository) GetQuestTemplate(ctx context.Context, templateID int) (*models.QuestTemplate, error) {
+	var tmpl models.QuestTemplate
+	err := r.db.GetContext(ctx, &tmpl, `SELECT * FROM quest_templates WHERE id = $1`, templateID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrQuestTemplateNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tmpl.Decode(); err != nil {
+		return nil, err
+	}
+
+	return &tmpl, nil
+}
+
+// DeleteQuestTemplate удаляет шаблон. Созданные по нему квесты остаются (template_id обнуляется).
+func (r *QuestRepository) DeleteQuestTemplate(ctx context.Context, templateID int) error {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM quest_templates WHERE id = $1`, templateID)
+	if err != nil {
+		return err
+	}
+
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrQuestTemplateNotFound
+	}
+
+	return nil
+}
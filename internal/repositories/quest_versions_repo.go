@@ -0,0 +1,172 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"BecomeOverMan/internal/models"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// questVersionColumns - поля квеста, которые фиксируются в quest_versions
+const questVersionColumns = `title, description, category, rarity, difficulty, price, tasks_count,
+	conditions_json, bonus_json, is_sequential, requires_confirmation,
+	window_policy, deadline_policy, reduced_reward_percent, reward_xp, reward_coin, time_limit_hours`
+
+// snapshotQuestVersionSQL сохраняет текущее состояние quests (последнюю версию) в quest_versions
+const snapshotQuestVersionSQL = `
+	INSERT INTO quest_versions (quest_id, version, ` + questVersionColumns + `)
+	SELECT id, version, ` + questVersionColumns + `
+	FROM quests WHERE id = $1`
+
+// pinnedQuestSQL - версия квеста, закрепленная за пользователем (алиас q совместим с запросами к quests)
+const pinnedQuestSQL = `
+	user_quests uq
+	INNER JOIN quest_versions q ON q.quest_id = uq.quest_id AND q.version = uq.quest_version`
+
+// pinQuestVersion подменяет поля квеста на версию, купленную пользователем, если она отличается от последней
+func pinQuestVersion(ctx context.Context, q sqlx.QueryerContext, quest *models.Quest, userID int) error {
+	var version int
+	err := sqlx.GetContext(ctx, q, &version, `
+		SELECT quest_version FROM user_quests WHERE user_id = $1 AND quest_id = $2
+	`, userID, quest.ID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if version == quest.Version {
+		return nil
+	}
+
+	return sqlx.GetContext(ctx, q, quest, `
+		SELECT version, `+questVersionColumns+`
+		FROM quest_versions WHERE quest_id = $1 AND version = $2
+	`, quest.ID, version)
+}
+
+// PublishQuestVersion публикует новую версию квеста с новым набором задач.
+// Пользователи, уже купившие квест, продолжают проходить свою версию.
+// Измененный квест пользователя возвращается в черновик и снова проходит модерацию.
+// Если expectedVersion > 0, версия публикуется, только если последняя версия квеста все еще expectedVersion
+// (изменение собрано из прочитанной версии), иначе - ErrQuestVersionConflict.
+func (r *QuestRepository) PublishQuestVersion(ctx context.Context, questID, expectedVersion int, quest *models.Quest, tasks []models.Task) (int, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var version int
+	err = tx.GetContext(ctx, &version, `
+		UPDATE quests SET
+			title = $2, description = $3, category = $4, rarity = $5, difficulty = $6,
+			price = $7, tasks_count = $8, conditions_json = $9, bonus_json = $10,
+			is_sequential = $11, requires_confirmation = $12,
+			window_policy = COALESCE(NULLIF($13, ''), 'allow'),
+			deadline_policy = COALESCE(NULLIF($14, ''), 'allow'),
+			reduced_reward_percent = COALESCE(NULLIF($15, 0), 50),
+			reward_xp = $16, reward_coin = $17, time_limit_hours = $18,
+			version = version + 1,
+			moderation_status = CASE WHEN author_id IS NULL THEN moderation_status ELSE 'draft' END
+		WHERE id = $1 AND ($19 = 0 OR version = $19)
+		RETURNING version
+	`, questID,
+		quest.Title, quest.Description, quest.Category, quest.Rarity, quest.Difficulty,
+		quest.Price, quest.TasksCount, quest.ConditionsJson, quest.BonusJson,
+		quest.IsSequential, quest.RequiresConfirmation,
+		quest.WindowPolicy, quest.DeadlinePolicy, quest.ReducedRewardPercent,
+		quest.RewardXP, quest.RewardCoin, quest.TimeLimitHours, expectedVersion,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		var exists bool
+		if err := tx.GetContext(ctx, &exists, `SELECT EXISTS(SELECT 1 FROM quests WHERE id = $1)`, questID); err != nil {
+			return 0, err
+		}
+		if exists {
+			return 0, ErrQuestVersionConflict
+		}
+		return 0, ErrQuestNotFound
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	if err := insertQuestTasks(tx.Tx, questID, version, tasks); err != nil {
+		return 0, err
+	}
+
+	if _, err := tx.ExecContext(ctx, snapshotQuestVersionSQL, questID); err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	return version, nil
+}
+
+// GetQuestVersions возвращает историю версий квеста (от первой к последней) с отличиями от предыдущей версии
+func (r *QuestRepository) GetQuestVersions(ctx context.Context, questID, userID int) ([]models.QuestVersion, error) {
+	visible, err := isQuestVisible(ctx, r.db, questID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !visible {
+		return nil, ErrQuestNotFound
+	}
+
+	var rows []struct {
+		models.Quest
+		CreatedAt time.Time `db:"created_at"`
+	}
+	err = r.db.SelectContext(ctx, &rows, `
+		SELECT quest_id AS id, version, created_at, `+questVersionColumns+`
+		FROM quest_versions
+		WHERE quest_id = $1
+		ORDER BY version
+	`, questID)
+	if err != nil {
+		return nil, err
+	}
+
+	var tasks []struct {
+		models.Task
+		Version int `db:"version"`
+	}
+	err = r.db.SelectContext(ctx, &tasks, `
+		SELECT t.*, qt.task_order, qt.required_occurrences, qt.version
+		FROM tasks t
+		INNER JOIN quest_tasks qt ON qt.task_id = t.id
+		WHERE qt.quest_id = $1
+		ORDER BY qt.version, qt.task_order
+	`, questID)
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int][]models.Task)
+	for _, t := range tasks {
+		byVersion[t.Version] = append(byVersion[t.Version], t.Task)
+	}
+
+	versions := make([]models.QuestVersion, 0, len(rows))
+	for i := range rows {
+		quest := rows[i].Quest
+		quest.Tasks = byVersion[quest.Version]
+
+		v := models.QuestVersion{Version: quest.Version, CreatedAt: rows[i].CreatedAt, Quest: quest}
+		if i > 0 {
+			v.Changes = models.DiffQuests(&versions[i-1].Quest, &quest)
+		}
+		versions = append(versions, v)
+	}
+
+	return versions, nil
+}
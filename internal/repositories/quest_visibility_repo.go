@@ -0,0 +1,92 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+var (
+	ErrQuestForbidden = errors.New("only the author can change this quest")
+)
+
+// questVisibleSQL - условие видимости квеста q пользователю userParam (например "$1"):
+// свои квесты, одобренные модератором публичные квесты и квесты друзей с видимостью friends,
+// а также уже купленные пользователем
+func questVisibleSQL(userParam string) string {
+	return `(
+		q.author_id = ` + userParam + `
+		OR (q.moderation_status = 'approved' AND (
+			q.visibility = 'public'
+			OR (q.visibility = 'friends' AND EXISTS (
+				SELECT 1 FROM friends f
+				WHERE f.status = 'accepted'
+				AND ((f.user_id = q.author_id AND f.friend_id = ` + userParam + `)
+					OR (f.friend_id = q.author_id AND f.user_id = ` + userParam + `))
+			))
+		))
+		OR EXISTS (
+			SELECT 1 FROM user_quests vuq
+			WHERE vuq.quest_id = q.id AND vuq.user_id = ` + userParam + `
+		)
+	)`
+}
+
+// isQuestVisible проверяет, что квест существует и виден пользователю
+func isQuestVisible(ctx context.Context, q sqlx.QueryerContext, questID, userID int) (bool, error) {
+	var visible bool
+	err := sqlx.GetContext(ctx, q, &visible, `
+		SELECT EXISTS(SELECT 1 FROM quests q WHERE q.id = $1 AND `+questVisibleSQL("$2")+`)
+	`, questID, userID)
+	return visible, err
+}
+
+// VisibleQuestIDs оставляет из ids только квесты, видимые пользователю
+func (r *QuestRepository) VisibleQuestIDs(ctx context.Context, ids []int, userID int) ([]int, error) {
+	visible := []int{}
+	if len(ids) == 0 {
+		return visible, nil
+	}
+
+	err := r.db.SelectContext(ctx, &visible, `
+		SELECT q.id FROM quests q
+		WHERE q.id = ANY($1) AND `+questVisibleSQL("$2")+`
+	`, pq.Array(ids), userID)
+	return visible, err
+}
+
+// checkQuestAuthor проверяет, что пользователь - автор квеста
+func checkQuestAuthor(ctx context.Context, q sqlx.QueryerContext, questID, userID int) error {
+	var authorID *int
+	err := sqlx.GetContext(ctx, q, &authorID, `SELECT author_id FROM quests WHERE id = $1`, questID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrQuestNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	if authorID == nil || *authorID != userID {
+		return ErrQuestForbidden
+	}
+
+	return nil
+}
+
+// CheckQuestAuthor проверяет, что пользователь может изменять квест
+func (r *QuestRepository) CheckQuestAuthor(ctx context.Context, questID, userID int) error {
+	return checkQuestAuthor(ctx, r.db, questID, userID)
+}
+
+// SetQuestVisibility меняет видимость квеста. Менять ее может только автор.
+func (r *QuestRepository) SetQuestVisibility(ctx context.Context, questID, userID int, visibility string) error {
+	if err := checkQuestAuthor(ctx, r.db, questID, userID); err != nil {
+		return err
+	}
+
+	_, err := r.db.ExecContext(ctx, `UPDATE quests SET visibility = $2 WHERE id = $1`, questID, visibility)
+	return err
+}
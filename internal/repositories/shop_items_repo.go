@@ -0,0 +1,145 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strconv"
+
+	"BecomeOverMan/internal/models"
+)
+
+var (
+	ErrShopItemNotFound       = errors.New("shop item not found")
+	ErrItemNotInInventory     = errors.New("item is not in the inventory")
+	ErrItemQuestNotExtendable = errors.New("quest is not started or has no deadline")
+)
+
+// GetShopItems возвращает каталог предметов, доступных для покупки
+func (r *QuestRepository) GetShopItems(ctx context.Context) ([]models.ShopItem, error) {
+	items := []models.ShopItem{}
+	err := r.db.SelectContext(ctx, &items, `SELECT * FROM shop_items WHERE is_active ORDER BY price, id`)
+	return items, err
+}
+
+// GetInventory возвращает предметы, которые есть у пользователя
+func (r *QuestRepository) GetInventory(ctx context.Context, userID int) ([]models.InventoryItem, error) {
+	items := []models.InventoryItem{}
+	err := r.db.SelectContext(ctx, &items, `
+		SELECT si.*, ui.quantity
+		FROM user_items ui
+		INNER JOIN shop_items si ON si.id = ui.item_id
+		WHERE ui.user_id = $1 AND ui.quantity > 0
+		ORDER BY si.id
+	`, userID)
+	return items, err
+}
+
+// PurchaseShopItem покупает quantity предметов: списывает монеты через журнал и кладет предметы в инвентарь
+func (r *QuestRepository) PurchaseShopItem(ctx context.Context, userID, itemID, quantity int) (*models.InventoryItem, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var item models.InventoryItem
+	err = tx.GetContext(ctx, &item.ShopItem, `SELECT * FROM shop_items WHERE id = $1 AND is_active FOR SHARE`, itemID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrShopItemNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Списываем валюту (при нехватке монет - ErrNotEnoughCoins)
+	_, err = writeCoinTransaction(ctx, tx, coinEntry{
+		UserID:        userID,
+		Amount:        -item.Price * quantity,
+		Type:          models.CoinTxSpent,
+		ReferenceType: models.CoinRefShopItem,
+		ReferenceID:   item.ID,
+		Description:   "Purchased item: " + item.Name + " x" + strconv.Itoa(quantity),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = tx.GetContext(ctx, &item.Quantity, `
+		INSERT INTO user_items (user_id, item_id, quantity)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (user_id, item_id) DO UPDATE SET quantity = user_items.quantity + EXCLUDED.quantity
+		RETURNING quantity
+	`, userID, itemID, quantity)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
+
+// UseItem использует один предмет из инвентаря. Заморозка серии и усилитель опыта выдаются как эффекты
+// (user_effects), продление срока сразу сдвигает expires_at начатого квеста questID.
+// Возвращает предмет с оставшимся количеством.
+func (r *QuestRepository) UseItem(ctx context.Context, userID, itemID int, questID *int) (*models.InventoryItem, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var item models.InventoryItem
+	err = tx.GetContext(ctx, &item.Quantity, `
+		UPDATE user_items SET quantity = quantity - 1
+		WHERE user_id = $1 AND item_id = $2 AND quantity > 0
+		RETURNING quantity
+	`, userID, itemID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrItemNotInInventory
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.GetContext(ctx, &item.ShopItem, `SELECT * FROM shop_items WHERE id = $1`, itemID); err != nil {
+		return nil, err
+	}
+
+	if effect := item.Effect(); effect != nil {
+		if err := grantBonusEffects(tx, ctx, userID, []models.BonusEffect{*effect}, "item", item.ID); err != nil {
+			return nil, err
+		}
+	} else if item.ItemType == models.ItemDeadlineExtension {
+		if questID == nil {
+			return nil, models.ErrItemQuestRequired
+		}
+
+		hours := 0
+		if item.DurationHours != nil {
+			hours = *item.DurationHours
+		}
+
+		// Продлить можно только квест, срок которого еще не истек (истекший уже проваливает воркер)
+		res, err := tx.ExecContext(ctx, `
+			UPDATE user_quests SET expires_at = expires_at + make_interval(hours => $1)
+			WHERE user_id = $2 AND quest_id = $3 AND status = 'started'
+			AND expires_at IS NOT NULL AND expires_at > NOW()
+		`, hours, userID, *questID)
+		if err != nil {
+			return nil, err
+		}
+		if n, err := res.RowsAffected(); err != nil {
+			return nil, err
+		} else if n == 0 {
+			return nil, ErrItemQuestNotExtendable
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
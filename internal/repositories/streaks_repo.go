@@ -0,0 +1,157 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"BecomeOverMan/internal/models"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// updateDailyStreak засчитывает сегодняшний день в серию пользователя (current_streak).
+// Серия растет, если предыдущий засчитанный день - вчера. Пропущенные дни закрываются заморозками
+// серии (эффект streak_freeze, одна заморозка на день); если заморозок не хватает, серия начинается заново.
+// Прежнее состояние серии сохраняется в prev_*, чтобы его можно было восстановить в revertDailyStreak.
+func updateDailyStreak(tx *sqlx.Tx, ctx context.Context, userID int) error {
+	var current, longest int
+	var last *time.Time
+	var today time.Time
+	err := tx.QueryRowContext(ctx, `
+		SELECT COALESCE(current_streak, 0), COALESCE(longest_streak, 0), last_streak_date, CURRENT_DATE
+		FROM users WHERE id = $1
+		FOR UPDATE
+	`, userID).Scan(&current, &longest, &last, &today)
+	if err != nil {
+		return err
+	}
+
+	prevCurrent, prevLongest, prevDate := current, longest, last
+
+	switch {
+	case last == nil:
+		current = 1
+	case !last.Before(today):
+		// Сегодняшний день уже засчитан
+		return nil
+	default:
+		missed := int(today.Sub(*last).Hours()/24) - 1
+		covered := true
+		if missed > 0 {
+			covered, err = useStreakFreezes(tx, ctx, userID, missed)
+			if err != nil {
+				return err
+			}
+		}
+		if covered {
+			current++
+			if missed > 0 {
+				// Пропущенные дни закрыты заморозками, при отмене серия не должна их потерять
+				yesterday := today.AddDate(0, 0, -1)
+				prevDate = &yesterday
+			}
+		} else {
+			current = 1
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE users
+		SET current_streak = $1, longest_streak = $2, last_streak_date = $3,
+			prev_current_streak = $4, prev_longest_streak = $5, prev_streak_date = $6
+		WHERE id = $7
+	`, current, max(longest, current), today, prevCurrent, prevLongest, prevDate, userID)
+	return err
+}
+
+// revertDailyStreak отменяет засчитывание сегодняшнего дня в серию, если после отмены выполнения
+// за сегодня не осталось ни одной выполненной задачи. Потраченные заморозки не возвращаются.
+func revertDailyStreak(tx *sqlx.Tx, ctx context.Context, userID int) error {
+	var stillCounted bool
+	err := tx.GetContext(ctx, &stillCounted, `
+		SELECT EXISTS (
+			SELECT 1 FROM user_tasks
+			WHERE user_id = $1 AND status = 'completed' AND completed_at >= CURRENT_DATE
+		) OR EXISTS (
+			SELECT 1 FROM user_task_occurrences
+			WHERE user_id = $1 AND status = 'completed' AND completed_at >= CURRENT_DATE
+		)`, userID)
+	if err != nil {
+		return err
+	}
+	if stillCounted {
+		return nil
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE users
+		SET current_streak = COALESCE(prev_current_streak, 0),
+			longest_streak = COALESCE(prev_longest_streak, longest_streak),
+			last_streak_date = prev_streak_date,
+			prev_current_streak = NULL, prev_longest_streak = NULL, prev_streak_date = NULL
+		WHERE id = $1 AND last_streak_date = CURRENT_DATE
+	`, userID)
+	return err
+}
+
+// ResetLapsedStreaks обнуляет серии, которые уже нельзя продолжить: последний засчитанный день
+// раньше вчерашнего, а заморозок не хватает, чтобы закрыть пропуск. Без этого current_streak
+// оставался бы прежним до следующего выполнения задачи.
+func (r *QuestRepository) ResetLapsedStreaks(ctx context.Context) (int64, error) {
+	res, err := r.db.ExecContext(ctx, `
+		UPDATE users u
+		SET current_streak = 0, last_streak_date = NULL,
+			prev_current_streak = NULL, prev_longest_streak = NULL, prev_streak_date = NULL
+		WHERE u.current_streak > 0
+		AND u.last_streak_date < CURRENT_DATE - 1 - (
+			SELECT COALESCE(SUM(e.uses_left), 0)::int FROM user_effects e
+			WHERE e.user_id = u.id AND e.effect_type = $1 AND e.uses_left > 0
+			AND (e.expires_at IS NULL OR e.expires_at > NOW())
+		)
+	`, models.EffectStreakFreeze)
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
+
+// useStreakFreezes списывает days заморозок серии, начиная с тех, что истекут раньше.
+// Если заморозок меньше days, ничего не списывает и возвращает false.
+func useStreakFreezes(tx *sqlx.Tx, ctx context.Context, userID, days int) (bool, error) {
+	var freezes []struct {
+		ID       int `db:"id"`
+		UsesLeft int `db:"uses_left"`
+	}
+	err := tx.SelectContext(ctx, &freezes, `
+		SELECT id, uses_left FROM user_effects
+		WHERE user_id = $1 AND effect_type = $2 AND uses_left > 0
+		AND (expires_at IS NULL OR expires_at > NOW())
+		ORDER BY expires_at NULLS LAST, created_at
+		FOR UPDATE
+	`, userID, models.EffectStreakFreeze)
+	if err != nil {
+		return false, err
+	}
+
+	total := 0
+	for _, f := range freezes {
+		total += f.UsesLeft
+	}
+	if total < days {
+		return false, nil
+	}
+
+	for _, f := range freezes {
+		if days == 0 {
+			break
+		}
+		used := min(f.UsesLeft, days)
+		if _, err := tx.ExecContext(ctx, `UPDATE user_effects SET uses_left = uses_left - $1 WHERE id = $2`, used, f.ID); err != nil {
+			return false, err
+		}
+		days -= used
+	}
+
+	return true, nil
+}
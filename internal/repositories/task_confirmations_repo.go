@@ -0,0 +1,210 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"BecomeOverMan/internal/models"
+
+	"github.com/jmoiron/sqlx"
+)
+
+var (
+	ErrConfirmerRequired        = errors.New("quest requires confirmation, choose a friend as confirmer first")
+	ErrConfirmerNotFriend       = errors.New("confirmer must be an accepted friend")
+	ErrTaskConfirmationNotFound = errors.New("task confirmation not found")
+)
+
+// SetQuestConfirmer назначает друга, который подтверждает выполнение задач квеста.
+// Уже отправленные и еще не рассмотренные запросы переходят к новому другу.
+func (r *QuestRepository) SetQuestConfirmer(ctx context.Context, userID, questID, confirmerID int) error {
+	if userID == confirmerID {
+		return ErrConfirmerNotFriend
+	}
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Подтверждать может только принятый друг
+	var areFriends bool
+	err = tx.GetContext(ctx, &areFriends, `
+		SELECT EXISTS(
+			SELECT 1 FROM friends 
+			WHERE ((user_id = $1 AND friend_id = $2) OR (user_id = $2 AND friend_id = $1))
+			AND status = 'accepted'
+		)`, userID, confirmerID)
+	if err != nil {
+		return err
+	}
+	if !areFriends {
+		return ErrConfirmerNotFriend
+	}
+
+	res, err := tx.ExecContext(ctx, `
+		UPDATE user_quests SET confirmer_id = $3
+		WHERE user_id = $1 AND quest_id = $2 AND status IN ('purchased', 'started')
+	`, userID, questID, confirmerID)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return errors.New("quest not found or already finished")
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE task_confirmations SET confirmer_id = $3
+		WHERE user_id = $1 AND quest_id = $2 AND status = 'pending'
+	`, userID, questID, confirmerID)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// requestTaskConfirmation отправляет выполненную задачу на подтверждение другу.
+// Обычная задача переходит в pending_confirmation, для повторяющейся ждет подтверждения вхождение occurrenceID.
+// rewardPercent фиксирует процент награды на момент выполнения, чтобы позднее одобрение его не меняло.
+func requestTaskConfirmation(tx *sqlx.Tx, ctx context.Context, userID, questID, taskID int, occurrenceID *int, rewardPercent int) error {
+	var confirmerID *int
+	var attempt int
+	err := tx.QueryRowContext(ctx, `
+		SELECT confirmer_id, attempt FROM user_quests WHERE user_id = $1 AND quest_id = $2
+	`, userID, questID).Scan(&confirmerID, &attempt)
+	if err != nil {
+		return err
+	}
+	if confirmerID == nil {
+		return ErrConfirmerRequired
+	}
+
+	if occurrenceID == nil {
+		_, err = tx.ExecContext(ctx, `
+			UPDATE user_tasks SET status = 'pending_confirmation'
+			WHERE user_id = $1 AND quest_id = $2 AND task_id = $3 AND status IN ('active', 'missed')
+		`, userID, questID, taskID)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO task_confirmations (user_id, confirmer_id, task_id, quest_id, attempt, occurrence_id, reward_percent)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`, userID, *confirmerID, taskID, questID, attempt, occurrenceID, rewardPercent)
+
+	return err
+}
+
+// pendingConfirmationsQuery - нерассмотренные запросы по начатым квестам (только текущая попытка)
+const pendingConfirmationsQuery = `
+	SELECT c.*, u.username, t.title AS task_title, q.title AS quest_title
+	FROM task_confirmations c
+	INNER JOIN user_quests uq ON uq.user_id = c.user_id AND uq.quest_id = c.quest_id
+		AND uq.attempt = c.attempt AND uq.status = 'started'
+	INNER JOIN users u ON u.id = c.user_id
+	INNER JOIN tasks t ON t.id = c.task_id
+	INNER JOIN quests q ON q.id = c.quest_id
+	WHERE c.confirmer_id = $1 AND c.status = 'pending'`
+
+// GetPendingConfirmations возвращает запросы на подтверждение, адресованные пользователю, вместе с доказательствами
+func (r *QuestRepository) GetPendingConfirmations(ctx context.Context, confirmerID int) ([]models.TaskConfirmation, error) {
+	confirmations := []models.TaskConfirmation{}
+	err := r.db.SelectContext(ctx, &confirmations, pendingConfirmationsQuery+` ORDER BY c.created_at`, confirmerID)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range confirmations {
+		c := &confirmations[i]
+		err := r.db.SelectContext(ctx, &c.Submissions, `
+			SELECT * FROM task_submissions
+			WHERE user_id = $1 AND quest_id = $2 AND task_id = $3 AND attempt = $4
+			AND status = 'accepted'
+			AND ($5::int IS NULL OR occurrence_id = $5)
+			ORDER BY created_at
+		`, c.UserID, c.QuestID, c.TaskID, c.Attempt, c.OccurrenceID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return confirmations, nil
+}
+
+// DecideTaskConfirmation одобряет или отклоняет запрос на подтверждение.
+// При одобрении пользователю начисляется награда за задачу, при отказе задачу нужно выполнить заново.
+func (r *QuestRepository) DecideTaskConfirmation(ctx context.Context, confirmerID, confirmationID int, approve bool, reason *string) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var c models.TaskConfirmation
+	err = tx.GetContext(ctx, &c, pendingConfirmationsQuery+` AND c.id = $2 FOR UPDATE OF c`, confirmerID, confirmationID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrTaskConfirmationNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	if approve {
+		if err := r.grantTaskReward(tx, ctx, c.UserID, c.QuestID, c.TaskID, c.OccurrenceID, c.RewardPercent); err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, `
+			UPDATE user_tasks SET is_confirmed = TRUE
+			WHERE user_id = $1 AND quest_id = $2 AND task_id = $3 AND status = 'completed'
+		`, c.UserID, c.QuestID, c.TaskID)
+		if err != nil {
+			return err
+		}
+	} else {
+		// Доказательства не засчитываются, задачу нужно выполнить (и подтвердить) заново
+		_, err = tx.ExecContext(ctx, `
+			UPDATE task_submissions SET status = 'rejected'
+			WHERE user_id = $1 AND quest_id = $2 AND task_id = $3 AND attempt = $4
+			AND status = 'accepted'
+			AND ($5::int IS NULL OR occurrence_id = $5)
+		`, c.UserID, c.QuestID, c.TaskID, c.Attempt, c.OccurrenceID)
+		if err != nil {
+			return err
+		}
+
+		if c.OccurrenceID != nil {
+			_, err = tx.ExecContext(ctx, `DELETE FROM user_task_occurrences WHERE id = $1`, *c.OccurrenceID)
+		} else {
+			_, err = tx.ExecContext(ctx, `
+				UPDATE user_tasks SET status = `+reopenedTaskStatusSQL+`
+				WHERE user_id = $1 AND quest_id = $2 AND task_id = $3 AND status = 'pending_confirmation'
+			`, c.UserID, c.QuestID, c.TaskID)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	status := models.ConfirmationRejected
+	if approve {
+		status = models.ConfirmationApproved
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE task_confirmations SET status = $2, reason = $3, decided_at = NOW()
+		WHERE id = $1
+	`, c.ID, status, reason)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
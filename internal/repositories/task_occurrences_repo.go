@@ -0,0 +1,121 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/jmoiron/sqlx"
+)
+
+var (
+	ErrTaskOnCooldown      = errors.New("task is on cooldown")
+	ErrTaskPeriodCompleted = errors.New("task already completed for the current period")
+)
+
+// taskPeriodStartSQL - начало текущего периода повторяющейся задачи t (день или неделя)
+const taskPeriodStartSQL = `date_trunc(CASE t.type WHEN 'daily' THEN 'day' ELSE 'week' END, NOW())`
+
+// taskOccurrencesColumns - прогресс по повторяющимся задачам для queryGetQuestDetails (учитывается только текущая попытка)
+const taskOccurrencesColumns = `
+			CASE WHEN t.type IN ('daily', 'weekly') AND uq.id IS NOT NULL THEN (
+				SELECT COUNT(*) FROM user_task_occurrences o
+				WHERE o.user_id = uq.user_id AND o.task_id = t.id AND o.quest_id = qt.quest_id
+				AND o.attempt = uq.attempt AND o.status = 'completed'
+			) END AS occurrences_done,
+			CASE WHEN t.type IN ('daily', 'weekly') AND uq.id IS NOT NULL THEN EXISTS (
+				SELECT 1 FROM user_task_occurrences o
+				WHERE o.user_id = uq.user_id AND o.task_id = t.id AND o.quest_id = qt.quest_id
+				AND o.attempt = uq.attempt AND o.status = 'completed'
+				AND o.period_start = ` + taskPeriodStartSQL + `
+			) END AS current_period_done`
+
+// createTaskOccurrence создает выполнение повторяющейся задачи за текущий период в статусе pending.
+// В рамках попытки квеста за период может быть только одно выполнение, а между выполнениями
+// должно пройти не меньше cooldownHours часов.
+func createTaskOccurrence(tx *sqlx.Tx, ctx context.Context, userID, questID, taskID, cooldownHours int) (int, error) {
+	// Проверяем кулдаун с последнего выполнения
+	if cooldownHours > 0 {
+		var onCooldown bool
+		err := tx.GetContext(ctx, &onCooldown, `
+			SELECT EXISTS (
+				SELECT 1 FROM user_task_occurrences
+				WHERE user_id = $1 AND task_id = $2 AND status IN ('pending', 'completed')
+				AND completed_at > NOW() - make_interval(hours => $3)
+			)`, userID, taskID, cooldownHours)
+		if err != nil {
+			return 0, err
+		}
+		if onCooldown {
+			return 0, ErrTaskOnCooldown
+		}
+	}
+
+	var occurrenceID int
+	err := tx.GetContext(ctx, &occurrenceID, `
+		INSERT INTO user_task_occurrences (
+			user_id, task_id, quest_id, attempt, period_start, period_end, status, completed_at
+		)
+		SELECT
+			$1, t.id, uq.quest_id, uq.attempt,
+			p.period_start,
+			p.period_start + CASE t.type WHEN 'daily' THEN interval '1 day' ELSE interval '1 week' END,
+			'pending', NOW()
+		FROM tasks t
+		CROSS JOIN LATERAL (SELECT `+taskPeriodStartSQL+` AS period_start) p
+		INNER JOIN user_quests uq ON uq.user_id = $1 AND uq.quest_id = $2
+		WHERE t.id = $3
+		ON CONFLICT (user_id, task_id, attempt, period_start) DO NOTHING
+		RETURNING id
+	`, userID, questID, taskID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, ErrTaskPeriodCompleted
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	return occurrenceID, nil
+}
+
+// completeTaskOccurrence засчитывает выполнение за период с уже начисленной наградой
+// и, когда набрано required_occurrences выполнений, завершает задачу в квесте.
+func completeTaskOccurrence(tx *sqlx.Tx, ctx context.Context, userID, questID, taskID, occurrenceID, xpGained, coinGained int) error {
+	_, err := tx.ExecContext(ctx, `
+		UPDATE user_task_occurrences
+		SET status = 'completed', xp_gained = $1, coin_gained = $2
+		WHERE id = $3
+	`, xpGained, coinGained, occurrenceID)
+	if err != nil {
+		return err
+	}
+
+	// Сколько периодов уже выполнено в текущей попытке и сколько нужно
+	var done, required int
+	err = tx.QueryRowContext(ctx, `
+		SELECT
+			(SELECT COUNT(*) FROM user_task_occurrences o
+			 INNER JOIN user_quests uq ON uq.user_id = o.user_id AND uq.quest_id = o.quest_id
+			 WHERE o.user_id = $1 AND o.quest_id = $2 AND o.task_id = $3
+			 AND o.attempt = uq.attempt AND o.status = 'completed'),
+			qt.required_occurrences
+		FROM quest_tasks qt
+		INNER JOIN user_quests uq ON uq.quest_id = qt.quest_id AND uq.quest_version = qt.version
+		WHERE uq.user_id = $1 AND qt.quest_id = $2 AND qt.task_id = $3
+	`, userID, questID, taskID).Scan(&done, &required)
+	if err != nil {
+		return err
+	}
+
+	// Копим награду в user_tasks и завершаем задачу, когда набрано нужное число периодов
+	_, err = tx.ExecContext(ctx, `
+		UPDATE user_tasks
+		SET xp_gained = xp_gained + $4,
+			coin_gained = coin_gained + $5,
+			status = CASE WHEN $6 THEN 'completed' ELSE status END,
+			completed_at = CASE WHEN $6 THEN NOW() ELSE completed_at END
+		WHERE user_id = $1 AND quest_id = $2 AND task_id = $3 AND status = 'active'
+	`, userID, questID, taskID, xpGained, coinGained, done >= required)
+
+	return err
+}
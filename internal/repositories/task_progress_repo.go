@@ -0,0 +1,106 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"BecomeOverMan/internal/models"
+
+	"github.com/jmoiron/sqlx"
+)
+
+var (
+	ErrTaskNotQuantitative  = errors.New("task has no target value")
+	ErrTaskTargetNotReached = errors.New("task target is not reached yet")
+)
+
+// taskProgressValueSQL - прогресс количественной задачи; для daily / weekly учитывается только текущий период
+const taskProgressValueSQL = `
+	CASE WHEN t.type IN ('daily', 'weekly')
+		AND ut.progress_period_start IS DISTINCT FROM ` + taskPeriodStartSQL + `
+	THEN 0 ELSE ut.progress_value END`
+
+// taskProgressColumns - прогресс по количественным задачам для queryGetQuestDetails
+const taskProgressColumns = `
+			CASE WHEN t.target_value IS NOT NULL AND ut.id IS NOT NULL THEN ` + taskProgressValueSQL + ` END AS progress_value,
+			CASE WHEN t.target_value > 0 AND ut.id IS NOT NULL THEN
+				LEAST(100, ROUND((` + taskProgressValueSQL + ` * 100 / t.target_value)::numeric, 1))::float8
+			END AS progress_percent`
+
+// AddTaskProgress добавляет прогресс к количественной задаче начатого квеста (delta может быть отрицательной для исправлений).
+// Для daily / weekly задач прогресс копится в рамках текущего периода.
+func (r *QuestRepository) AddTaskProgress(ctx context.Context, userID, questID, taskID int, delta float64) (*models.TaskProgress, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var targetValue *float64
+	err = tx.GetContext(ctx, &targetValue, `
+		SELECT t.target_value
+		FROM tasks t
+		INNER JOIN quest_tasks qt ON qt.task_id = t.id
+		WHERE qt.quest_id = $1 AND t.id = $2
+	`, questID, taskID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, errors.New("quest or task not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if targetValue == nil {
+		return nil, ErrTaskNotQuantitative
+	}
+
+	if err := checkQuestNotExpired(tx, ctx, userID, questID); err != nil {
+		return nil, err
+	}
+
+	progress := models.TaskProgress{QuestID: questID, TaskID: taskID}
+	err = tx.GetContext(ctx, &progress, `
+		UPDATE user_tasks ut
+		SET progress_value = GREATEST(`+taskProgressValueSQL+` + $4, 0),
+			progress_period_start = CASE WHEN t.type IN ('daily', 'weekly') THEN `+taskPeriodStartSQL+` END
+		FROM tasks t, user_quests uq
+		WHERE ut.user_id = $1 AND ut.quest_id = $2 AND ut.task_id = $3
+		AND ut.status IN ('active', 'missed')
+		AND t.id = ut.task_id
+		AND uq.user_id = ut.user_id AND uq.quest_id = ut.quest_id AND uq.status = 'started'
+		RETURNING ut.quest_id, ut.task_id, ut.status, ut.progress_value, t.target_value, t.unit
+	`, userID, questID, taskID, delta)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, errors.New("quest or task not found or already completed")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	progress.SetPercent()
+	return &progress, nil
+}
+
+// checkTaskTargetReached для количественной задачи проверяет, что цель достигнута
+func checkTaskTargetReached(tx *sqlx.Tx, ctx context.Context, userID, questID, taskID int) error {
+	var notReached bool
+	err := tx.GetContext(ctx, &notReached, `
+		SELECT t.target_value IS NOT NULL AND `+taskProgressValueSQL+` < t.target_value
+		FROM user_tasks ut
+		INNER JOIN tasks t ON t.id = ut.task_id
+		WHERE ut.user_id = $1 AND ut.quest_id = $2 AND ut.task_id = $3
+	`, userID, questID, taskID)
+	if err != nil {
+		return err
+	}
+
+	if notReached {
+		return ErrTaskTargetNotReached
+	}
+
+	return nil
+}
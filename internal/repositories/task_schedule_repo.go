@@ -0,0 +1,141 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+
+	"BecomeOverMan/internal/models"
+
+	"github.com/jmoiron/sqlx"
+)
+
+var (
+	ErrTaskOutsideWindow  = errors.New("task can't be completed outside its scheduled window")
+	ErrTaskDeadlineMissed = errors.New("task deadline is missed")
+)
+
+// taskRewardPercent применяет политики квеста к расписанию задачи пользователя (scheduled_start, scheduled_end, deadline)
+// и возвращает процент награды за выполнение задачи сейчас. Если политика запрещает выполнение - ошибка.
+func taskRewardPercent(tx *sqlx.Tx, ctx context.Context, userID, questID, taskID int) (int, error) {
+	var s struct {
+		OutsideWindow        bool   `db:"outside_window"`
+		PastDeadline         bool   `db:"past_deadline"`
+		WindowPolicy         string `db:"window_policy"`
+		DeadlinePolicy       string `db:"deadline_policy"`
+		ReducedRewardPercent int    `db:"reduced_reward_percent"`
+	}
+	err := tx.GetContext(ctx, &s, `
+		SELECT
+			COALESCE(NOW() < ut.scheduled_start OR NOW() > ut.scheduled_end, FALSE) AS outside_window,
+			COALESCE(NOW() > ut.deadline, FALSE) AS past_deadline,
+			q.window_policy, q.deadline_policy, q.reduced_reward_percent
+		FROM user_tasks ut
+		INNER JOIN `+pinnedQuestSQL+` ON uq.user_id = ut.user_id AND uq.quest_id = ut.quest_id
+		WHERE ut.user_id = $1 AND ut.quest_id = $2 AND ut.task_id = $3
+	`, userID, questID, taskID)
+	if err != nil {
+		return 0, err
+	}
+
+	percent := 100
+	apply := func(violated bool, policy string, rejectErr error) error {
+		if !violated {
+			return nil
+		}
+		switch policy {
+		case models.SchedulePolicyReject:
+			return rejectErr
+		case models.SchedulePolicyReduce:
+			percent = min(percent, s.ReducedRewardPercent)
+		}
+		return nil
+	}
+
+	if err := apply(s.OutsideWindow, s.WindowPolicy, ErrTaskOutsideWindow); err != nil {
+		return 0, err
+	}
+	if err := apply(s.PastDeadline, s.DeadlinePolicy, ErrTaskDeadlineMissed); err != nil {
+		return 0, err
+	}
+
+	return percent, nil
+}
+
+// DeadlineFailedQuest - начатый квест, проваленный из-за пропущенного дедлайна задачи (deadline_policy = reject)
+type DeadlineFailedQuest struct {
+	UserID  int `db:"user_id"`
+	QuestID int `db:"quest_id"`
+}
+
+// MarkMissedTasks переводит активные задачи начатых квестов с истекшим deadline в статус missed.
+// Выполнить missed задачу можно, если это разрешает deadline_policy квеста. При deadline_policy = reject
+// квест уже не завершить, поэтому он сразу проваливается (и его можно пройти заново через retry).
+// Повторяющиеся задачи не учитываются - у них нет единого дедлайна.
+func (r *QuestRepository) MarkMissedTasks(ctx context.Context) (int64, []DeadlineFailedQuest, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, `
+		UPDATE user_tasks ut
+		SET status = 'missed'
+		FROM tasks t, user_quests uq
+		WHERE ut.status = 'active'
+		AND ut.deadline IS NOT NULL AND ut.deadline <= NOW()
+		AND t.id = ut.task_id AND t.type NOT IN ('daily', 'weekly')
+		AND uq.user_id = ut.user_id AND uq.quest_id = ut.quest_id AND uq.status = 'started'
+	`)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	missed, err := res.RowsAffected()
+	if err != nil {
+		return 0, nil, err
+	}
+
+	// Missed задача могла появиться и при отмене выполнения / отказе в подтверждении,
+	// поэтому проверяются все начатые квесты с reject-политикой, а не только что отмеченные задачи
+	var failed []DeadlineFailedQuest
+	err = tx.SelectContext(ctx, &failed, `
+		UPDATE user_quests
+		SET status = 'failed'
+		WHERE id IN (
+			SELECT uq.id FROM `+pinnedQuestSQL+`
+			WHERE uq.status = 'started' AND q.deadline_policy = $1
+			AND EXISTS (
+				SELECT 1 FROM user_tasks ut
+				WHERE ut.user_id = uq.user_id AND ut.quest_id = uq.quest_id AND ut.status = 'missed'
+			)
+			FOR UPDATE OF uq SKIP LOCKED
+		)
+		RETURNING user_id, quest_id
+	`, models.SchedulePolicyReject)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	if len(failed) > 0 {
+		userIDs := make([]int, len(failed))
+		questIDs := make([]int, len(failed))
+		for i, f := range failed {
+			userIDs[i] = f.UserID
+			questIDs[i] = f.QuestID
+		}
+
+		if err := failUserQuestTasks(tx, ctx, userIDs, questIDs); err != nil {
+			return 0, nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, nil, err
+	}
+
+	return missed, failed, nil
+}
+
+// reopenedTaskStatusSQL - статус, в который возвращается задача при отмене выполнения или отказе в подтверждении
+const reopenedTaskStatusSQL = `CASE WHEN deadline IS NOT NULL AND deadline <= NOW() THEN 'missed' ELSE 'active' END`
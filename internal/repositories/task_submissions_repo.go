@@ -0,0 +1,160 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"BecomeOverMan/internal/models"
+
+	"github.com/jmoiron/sqlx"
+)
+
+var (
+	ErrProofRequired          = errors.New("task requires a proof of completion, submit it first")
+	ErrTaskSubmissionNotFound = errors.New("task submission not found")
+	ErrSubmissionTaskNotFound = errors.New("quest or task not found or already completed")
+)
+
+// GetTaskProofType возвращает тип доказательства, который требует задача квеста.
+// Задача должна быть активной (или пропущенной) у пользователя в начатом квесте,
+// иначе - ErrSubmissionTaskNotFound: доказательство (и его файл) сохранять некуда.
+func (r *QuestRepository) GetTaskProofType(ctx context.Context, userID, questID, taskID int) (string, error) {
+	var proofType string
+	err := r.db.GetContext(ctx, &proofType, `
+		SELECT t.proof_type
+		FROM user_tasks ut
+		INNER JOIN user_quests uq ON uq.user_id = ut.user_id AND uq.quest_id = ut.quest_id
+		INNER JOIN tasks t ON t.id = ut.task_id
+		WHERE ut.user_id = $1 AND ut.quest_id = $2 AND ut.task_id = $3
+		AND ut.status IN ('active', 'missed') AND uq.status = 'started'
+	`, userID, questID, taskID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", ErrSubmissionTaskNotFound
+	}
+
+	return proofType, err
+}
+
+// CreateTaskSubmission сохраняет доказательство выполнения активной задачи начатого квеста
+func (r *QuestRepository) CreateTaskSubmission(ctx context.Context, s models.TaskSubmission) (*models.TaskSubmission, error) {
+	var created models.TaskSubmission
+	err := r.db.GetContext(ctx, &created, `
+		INSERT INTO task_submissions (
+			user_id, task_id, quest_id, attempt, proof_type,
+			text_value, numeric_value, file_key, file_name, content_type
+		)
+		SELECT ut.user_id, ut.task_id, ut.quest_id, uq.attempt, $4, $5, $6, $7, $8, $9
+		FROM user_tasks ut
+		INNER JOIN user_quests uq ON uq.user_id = ut.user_id AND uq.quest_id = ut.quest_id
+		WHERE ut.user_id = $1 AND ut.quest_id = $2 AND ut.task_id = $3
+		AND ut.status IN ('active', 'missed') AND uq.status = 'started'
+		RETURNING *
+	`, s.UserID, s.QuestID, s.TaskID, s.ProofType,
+		s.TextValue, s.NumericValue, s.FileKey, s.FileName, s.ContentType)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrSubmissionTaskNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &created, nil
+}
+
+// GetTaskSubmission возвращает доказательство по ID, если userID - его автор или друг, подтверждающий
+// выполнение задач этого квеста (confirmer_id квеста или запрос на подтверждение этой задачи)
+func (r *QuestRepository) GetTaskSubmission(ctx context.Context, userID, submissionID int) (*models.TaskSubmission, error) {
+	var submission models.TaskSubmission
+	err := r.db.GetContext(ctx, &submission, `
+		SELECT ts.* FROM task_submissions ts
+		WHERE ts.id = $1 AND (
+			ts.user_id = $2
+			OR EXISTS (
+				SELECT 1 FROM user_quests uq
+				WHERE uq.user_id = ts.user_id AND uq.quest_id = ts.quest_id AND uq.confirmer_id = $2
+			)
+			OR EXISTS (
+				SELECT 1 FROM task_confirmations tc
+				WHERE tc.user_id = ts.user_id AND tc.quest_id = ts.quest_id AND tc.task_id = ts.task_id
+				AND tc.attempt = ts.attempt AND tc.confirmer_id = $2
+			)
+		)
+	`, submissionID, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrTaskSubmissionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &submission, nil
+}
+
+// acceptTaskSubmissions засчитывает присланные в текущей попытке доказательства при выполнении задачи.
+// Для задач без proof_type ничего не делает, для остальных без доказательства - ErrProofRequired.
+// occurrenceID задается для повторяющихся задач, чтобы доказательство относилось к конкретному периоду.
+func acceptTaskSubmissions(tx *sqlx.Tx, ctx context.Context, userID, questID, taskID int, occurrenceID *int) error {
+	var proofType string
+	err := tx.GetContext(ctx, &proofType, `SELECT proof_type FROM tasks WHERE id = $1`, taskID)
+	if err != nil {
+		return err
+	}
+
+	if proofType == models.ProofTypeNone {
+		return nil
+	}
+
+	res, err := tx.ExecContext(ctx, `
+		UPDATE task_submissions ts
+		SET status = 'accepted', accepted_at = NOW(), occurrence_id = $4
+		FROM user_quests uq
+		WHERE ts.user_id = $1 AND ts.quest_id = $2 AND ts.task_id = $3
+		AND ts.status = 'pending'
+		AND uq.user_id = ts.user_id AND uq.quest_id = ts.quest_id AND ts.attempt = uq.attempt
+	`, userID, questID, taskID, occurrenceID)
+	if err != nil {
+		return err
+	}
+
+	accepted, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if accepted == 0 {
+		return ErrProofRequired
+	}
+
+	return nil
+}
+
+// attachTaskSubmissions подгружает доказательства пользователя по задачам квеста (текущая попытка)
+func attachTaskSubmissions(ctx context.Context, q sqlx.QueryerContext, quest *models.Quest, userID int) error {
+	if len(quest.Tasks) == 0 {
+		return nil
+	}
+
+	var submissions []models.TaskSubmission
+	err := sqlx.SelectContext(ctx, q, &submissions, `
+		SELECT ts.* FROM task_submissions ts
+		INNER JOIN user_quests uq
+			ON uq.user_id = ts.user_id AND uq.quest_id = ts.quest_id AND uq.attempt = ts.attempt
+		WHERE ts.user_id = $1 AND ts.quest_id = $2
+		ORDER BY ts.created_at
+	`, userID, quest.ID)
+	if err != nil {
+		return err
+	}
+
+	byTask := make(map[int][]models.TaskSubmission)
+	for _, s := range submissions {
+		byTask[s.TaskID] = append(byTask[s.TaskID], s)
+	}
+
+	for i := range quest.Tasks {
+		quest.Tasks[i].Submissions = byTask[quest.Tasks[i].ID]
+	}
+
+	return nil
+}
@@ -0,0 +1,204 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"BecomeOverMan/internal/models"
+
+	"github.com/jmoiron/sqlx"
+)
+
+var (
+	ErrTaskUndoExpired        = errors.New("task completion can no longer be undone")
+	ErrTaskUndoNotEnoughCoins = errors.New("not enough currency to undo task completion")
+	ErrTaskUndoLocked         = errors.New("later tasks of the sequential quest are already completed")
+)
+
+// UndoTaskCompletion отменяет выполнение задачи, если с него прошло не больше window и квест еще не завершен.
+// Списывает ровно те xp_gained / coin_gained, что были начислены за выполнение, пересчитывает уровень
+// и записывает компенсирующую транзакцию. Для daily / weekly задач отменяется последнее выполнение за период.
+func (r *QuestRepository) UndoTaskCompletion(ctx context.Context, userID, questID, taskID int, window time.Duration) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Квест должен быть в процессе выполнения; блокируем его, чтобы он не завершился параллельно
+	var attempt int
+	err = tx.GetContext(ctx, &attempt, `
+		SELECT attempt FROM user_quests
+		WHERE user_id = $1 AND quest_id = $2 AND status = 'started'
+		FOR UPDATE
+	`, userID, questID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return errors.New("quest is not in started state")
+	}
+	if err != nil {
+		return err
+	}
+
+	var taskType, title string
+	err = tx.QueryRowContext(ctx, `SELECT type, title FROM tasks WHERE id = $1`, taskID).Scan(&taskType, &title)
+	if errors.Is(err, sql.ErrNoRows) {
+		return errors.New("task not found")
+	}
+	if err != nil {
+		return err
+	}
+
+	// В последовательном квесте нельзя отменить задачу, после которой уже выполнены следующие
+	laterCompleted, err := hasSequentialOrderConflict(tx, ctx, userID, questID, taskID, false)
+	if err != nil {
+		return err
+	}
+	if laterCompleted {
+		return ErrTaskUndoLocked
+	}
+
+	windowSeconds := int(window.Seconds())
+
+	var xpGained, coinGained int
+	if models.IsRecurringTaskType(taskType) {
+		// Последнее засчитанное выполнение повторяющейся задачи в текущей попытке
+		var occurrenceID int
+		err = tx.QueryRowContext(ctx, `
+			SELECT id, xp_gained, coin_gained FROM user_task_occurrences
+			WHERE user_id = $1 AND quest_id = $2 AND task_id = $3 AND attempt = $4
+			AND status = 'completed'
+			AND completed_at > NOW() - make_interval(secs => $5)
+			ORDER BY completed_at DESC
+			LIMIT 1
+			FOR UPDATE
+		`, userID, questID, taskID, attempt, windowSeconds).Scan(&occurrenceID, &xpGained, &coinGained)
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrTaskUndoExpired
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := reopenTaskSubmissions(tx, ctx, userID, questID, taskID, attempt, &occurrenceID); err != nil {
+			return err
+		}
+
+		if _, err = tx.ExecContext(ctx, `DELETE FROM user_task_occurrences WHERE id = $1`, occurrenceID); err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, `
+			UPDATE user_tasks
+			SET status = 'active',
+				completed_at = NULL,
+				is_confirmed = FALSE,
+				xp_gained = GREATEST(xp_gained - $4, 0),
+				coin_gained = GREATEST(coin_gained - $5, 0)
+			WHERE user_id = $1 AND quest_id = $2 AND task_id = $3
+		`, userID, questID, taskID, xpGained, coinGained)
+		if err != nil {
+			return err
+		}
+	} else {
+		err = tx.QueryRowContext(ctx, `
+			SELECT xp_gained, coin_gained FROM user_tasks
+			WHERE user_id = $1 AND quest_id = $2 AND task_id = $3
+			AND status = 'completed'
+			AND completed_at > NOW() - make_interval(secs => $4)
+			FOR UPDATE
+		`, userID, questID, taskID, windowSeconds).Scan(&xpGained, &coinGained)
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrTaskUndoExpired
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := reopenTaskSubmissions(tx, ctx, userID, questID, taskID, attempt, nil); err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, `
+			UPDATE user_tasks
+			SET status = `+reopenedTaskStatusSQL+`,
+				completed_at = NULL,
+				is_confirmed = FALSE,
+				xp_gained = 0,
+				coin_gained = 0
+			WHERE user_id = $1 AND quest_id = $2 AND task_id = $3
+		`, userID, questID, taskID)
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := addXPWithLevelUp(tx, ctx, userID, -xpGained); err != nil {
+		return err
+	}
+
+	// Если это было единственное выполнение за сегодня, день больше не засчитан в серию
+	if err := revertDailyStreak(tx, ctx, userID); err != nil {
+		return err
+	}
+
+	// Компенсирующая транзакция. Монеты могли быть уже потрачены
+	_, err = writeCoinTransaction(ctx, tx, coinEntry{
+		UserID:        userID,
+		Amount:        -coinGained,
+		Type:          models.CoinTxReversal,
+		ReferenceType: models.CoinRefTask,
+		ReferenceID:   taskID,
+		Description:   "Undone task: " + title,
+	})
+	if errors.Is(err, ErrNotEnoughCoins) {
+		return ErrTaskUndoNotEnoughCoins
+	}
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// hasSequentialOrderConflict проверяет порядок задач последовательного квеста (для обычного квеста всегда false).
+// earlier = true: есть невыполненные задачи раньше taskID, поэтому его еще нельзя выполнить.
+// earlier = false: есть выполненные задачи позже taskID, поэтому его выполнение уже нельзя отменить.
+func hasSequentialOrderConflict(tx *sqlx.Tx, ctx context.Context, userID, questID, taskID int, earlier bool) (bool, error) {
+	var conflict bool
+	err := tx.GetContext(ctx, &conflict, `
+		SELECT COALESCE(q.is_sequential, FALSE) AND EXISTS (
+			SELECT 1
+			FROM quest_tasks qt
+			INNER JOIN quest_tasks cur
+				ON cur.quest_id = qt.quest_id AND cur.version = qt.version AND cur.task_id = $3
+			INNER JOIN user_tasks ut
+				ON ut.task_id = qt.task_id AND ut.quest_id = qt.quest_id AND ut.user_id = $1
+			WHERE qt.quest_id = $2 AND qt.version = q.version
+			AND CASE WHEN $4
+				THEN qt.task_order < cur.task_order AND ut.status != 'completed'
+				ELSE qt.task_order > cur.task_order AND ut.status = 'completed'
+			END
+		)
+		FROM `+pinnedQuestSQL+`
+		WHERE uq.user_id = $1 AND uq.quest_id = $2
+		`, userID, questID, taskID, earlier,
+	)
+
+	return conflict, err
+}
+
+// reopenTaskSubmissions возвращает засчитанные доказательства в pending,
+// чтобы при повторном выполнении задачи не присылать их заново
+func reopenTaskSubmissions(tx *sqlx.Tx, ctx context.Context, userID, questID, taskID, attempt int, occurrenceID *int) error {
+	_, err := tx.ExecContext(ctx, `
+		UPDATE task_submissions
+		SET status = 'pending', accepted_at = NULL, occurrence_id = NULL
+		WHERE user_id = $1 AND quest_id = $2 AND task_id = $3 AND attempt = $4
+		AND status = 'accepted'
+		AND ($5::int IS NULL OR occurrence_id = $5)
+	`, userID, questID, taskID, attempt, occurrenceID)
+
+	return err
+}
@@ -0,0 +1,139 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"BecomeOverMan/internal/models"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+var (
+	ErrTaskVariantRequired = errors.New("choose a task variant before completing the task")
+	ErrTaskVariantNotFound = errors.New("task variant not found")
+)
+
+// attachTaskVariants подгружает варианты для всех задач квеста одним запросом
+func attachTaskVariants(ctx context.Context, q sqlx.QueryerContext, quest *models.Quest) error {
+	if len(quest.Tasks) == 0 {
+		return nil
+	}
+
+	taskIDs := make([]int, len(quest.Tasks))
+	for i, t := range quest.Tasks {
+		taskIDs[i] = t.ID
+	}
+
+	var variants []models.TaskVariant
+	err := sqlx.SelectContext(ctx, q, &variants, `
+		SELECT * FROM task_variants
+		WHERE task_id = ANY($1)
+		ORDER BY task_id, id
+	`, pq.Array(taskIDs))
+	if err != nil {
+		return err
+	}
+
+	byTask := make(map[int][]models.TaskVariant, len(quest.Tasks))
+	for _, v := range variants {
+		byTask[v.TaskID] = append(byTask[v.TaskID], v)
+	}
+
+	for i := range quest.Tasks {
+		quest.Tasks[i].Variants = byTask[quest.Tasks[i].ID]
+	}
+
+	return nil
+}
+
+// insertTaskVariants сохраняет варианты задачи (используется при создании квеста)
+func insertTaskVariants(tx *sql.Tx, taskID int, variants []models.TaskVariant) error {
+	for _, v := range variants {
+		_, err := tx.Exec(`
+			INSERT INTO task_variants (task_id, title, description, base_xp_reward, base_coin_reward)
+			VALUES ($1, $2, $3, $4, $5)
+		`, taskID, v.Title, v.Description, v.BaseXpReward, v.BaseCoinReward)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// taskRewardForUser возвращает награду за задачу с учетом выбранного пользователем варианта.
+// Если у задачи есть варианты, а пользователь ни один не выбрал - ErrTaskVariantRequired.
+func taskRewardForUser(tx *sqlx.Tx, ctx context.Context, userID, questID, taskID, baseXpReward, baseCoinReward int) (int, int, error) {
+	var hasVariants bool
+	err := tx.GetContext(ctx, &hasVariants, `SELECT EXISTS (SELECT 1 FROM task_variants WHERE task_id = $1)`, taskID)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if !hasVariants {
+		return baseXpReward, baseCoinReward, nil
+	}
+
+	var reward struct {
+		XP   *int `db:"base_xp_reward"`
+		Coin *int `db:"base_coin_reward"`
+	}
+	err = tx.GetContext(ctx, &reward, `
+		SELECT tv.base_xp_reward, tv.base_coin_reward
+		FROM user_tasks ut
+		LEFT JOIN task_variants tv ON tv.id = ut.variant_id
+		WHERE ut.user_id = $1 AND ut.quest_id = $2 AND ut.task_id = $3
+	`, userID, questID, taskID)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if reward.XP == nil || reward.Coin == nil {
+		return 0, 0, ErrTaskVariantRequired
+	}
+
+	return *reward.XP, *reward.Coin, nil
+}
+
+// ChooseTaskVariant сохраняет выбранный пользователем вариант задачи.
+// Выбрать (или поменять) вариант можно, пока задача не выполнена.
+func (r *QuestRepository) ChooseTaskVariant(ctx context.Context, userID, questID, taskID, variantID int) error {
+	var belongs bool
+	err := r.db.GetContext(ctx, &belongs, `
+		SELECT EXISTS (SELECT 1 FROM task_variants WHERE id = $1 AND task_id = $2)
+	`, variantID, taskID)
+	if err != nil {
+		return err
+	}
+
+	if !belongs {
+		return ErrTaskVariantNotFound
+	}
+
+	res, err := r.db.ExecContext(ctx, `
+		UPDATE user_tasks ut
+		SET variant_id = $4
+		FROM user_quests uq
+		WHERE ut.user_id = $1 AND ut.quest_id = $2 AND ut.task_id = $3
+		AND ut.status IN ('not_started', 'active', 'missed')
+		AND uq.user_id = ut.user_id AND uq.quest_id = ut.quest_id
+		AND uq.status IN ('purchased', 'started')
+	`, userID, questID, taskID, variantID)
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return errors.New("quest or task not found or already completed")
+	}
+
+	return nil
+}
@@ -128,7 +128,23 @@ func (r *UserRepository) UpdateUser(id int, req models.UpdateUserRequest) (model
 }
 
 func (r *UserRepository) DeleteUser(id int) (bool, error) {
-	res, err := r.db.Exec(`DELETE FROM users WHERE id = $1`, id)
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	// Отзывы пользователя удалятся каскадно - убираем его оценки из агрегатов квестов
+	_, err = tx.Exec(`
+		UPDATE quests q
+		SET rating_sum = q.rating_sum - qr.rating, rating_count = q.rating_count - 1
+		FROM quest_reviews qr
+		WHERE qr.quest_id = q.id AND qr.user_id = $1`, id)
+	if err != nil {
+		return false, err
+	}
+
+	res, err := tx.Exec(`DELETE FROM users WHERE id = $1`, id)
 	if err != nil {
 		return false, err
 	}
@@ -136,5 +152,5 @@ func (r *UserRepository) DeleteUser(id int) (bool, error) {
 	if err != nil {
 		return false, err
 	}
-	return rows > 0, nil
+	return rows > 0, tx.Commit()
 }
@@ -0,0 +1,17 @@
+package services
+
+import (
+	"BecomeOverMan/internal/models"
+	"BecomeOverMan/internal/repositories"
+	"context"
+)
+
+var ErrNotEnoughCoins = repositories.ErrNotEnoughCoins
+
+// GetCoinTransactions возвращает страницу истории транзакций монет пользователя
+func (s *QuestService) GetCoinTransactions(ctx context.Context, userID int, filter models.CoinTransactionsFilter) (*models.CoinTransactionsPage, error) {
+	if filter.Limit <= 0 {
+		filter.Limit = models.DefaultCoinTransactionsLimit
+	}
+	return s.questRepo.GetCoinTransactions(ctx, userID, filter)
+}
@@ -0,0 +1,107 @@
+package services
+
+import (
+	"BecomeOverMan/internal/models"
+	"BecomeOverMan/internal/repositories"
+	"context"
+	"log/slog"
+	"time"
+)
+
+var (
+	ErrInvalidEvent        = models.ErrInvalidEvent
+	ErrEventNotFound       = repositories.ErrEventNotFound
+	ErrEventClosed         = repositories.ErrEventClosed
+	ErrEventNotActive      = repositories.ErrEventNotActive
+	ErrEventQuestTaken     = repositories.ErrEventQuestTaken
+	ErrEventQuestNotSystem = repositories.ErrEventQuestNotSystem
+	ErrEventQuestNotFound  = repositories.ErrEventQuestNotFound
+)
+
+// defaultLeaderboardLimit - сколько мест таблицы лидеров отдается по умолчанию
+const defaultLeaderboardLimit = 100
+
+// CreateEvent создает событие с наградами за места
+func (s *QuestService) CreateEvent(ctx context.Context, userID int, req models.CreateEventRequest) (*models.Event, error) {
+	if err := models.ValidateEventRewards(req.Rewards); err != nil {
+		return nil, err
+	}
+
+	event := &models.Event{
+		Title:       req.Title,
+		Description: req.Description,
+		StartsAt:    req.StartsAt,
+		EndsAt:      req.EndsAt,
+		Rewards:     req.Rewards,
+		CreatedBy:   &userID,
+	}
+	if event.Rewards == nil {
+		event.Rewards = []models.EventReward{}
+	}
+
+	if err := s.questRepo.CreateEvent(ctx, event); err != nil {
+		return nil, err
+	}
+	return event, nil
+}
+
+func (s *QuestService) GetEvents(ctx context.Context, status string) ([]models.Event, error) {
+	return s.questRepo.GetEvents(ctx, status)
+}
+
+// GetEvent возвращает событие с квестами и местом пользователя в таблице лидеров
+func (s *QuestService) GetEvent(ctx context.Context, eventID, userID int) (*models.Event, error) {
+	return s.questRepo.GetEvent(ctx, eventID, userID)
+}
+
+func (s *QuestService) GetEventLeaderboard(ctx context.Context, eventID, limit int) ([]models.EventStanding, error) {
+	if limit <= 0 {
+		limit = defaultLeaderboardLimit
+	}
+	return s.questRepo.GetEventLeaderboard(ctx, eventID, limit)
+}
+
+// AddEventQuest делает системный квест квестом события: он виден только во время события и приносит очки
+func (s *QuestService) AddEventQuest(ctx context.Context, eventID int, req models.AddEventQuestRequest) error {
+	return s.questRepo.AddEventQuest(ctx, eventID, req.QuestID, req.Points)
+}
+
+func (s *QuestService) RemoveEventQuest(ctx context.Context, eventID, questID int) error {
+	return s.questRepo.RemoveEventQuest(ctx, eventID, questID)
+}
+
+// RunEventWorker периодически подводит итоги закончившихся событий и выдает награды за места.
+// Блокируется до отмены ctx, поэтому запускается в отдельной горутине.
+func (s *QuestService) RunEventWorker(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	slog.Info("Event worker started", "interval", interval)
+
+	for {
+		s.closeFinishedEvents(ctx)
+
+		select {
+		case <-ctx.Done():
+			slog.Info("Event worker stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// closeFinishedEvents закрывает все закончившиеся события по одному
+func (s *QuestService) closeFinishedEvents(ctx context.Context) {
+	for {
+		event, standings, err := s.questRepo.CloseFinishedEvent(ctx)
+		if err != nil {
+			slog.Error("Failed to close finished event", "error", err)
+			return
+		}
+		if event == nil {
+			return
+		}
+
+		slog.Info("Event closed", "event_id", event.ID, "title", event.Title, "participants", len(standings))
+	}
+}
@@ -0,0 +1,44 @@
+package services
+
+import (
+	"BecomeOverMan/internal/models"
+	"context"
+	"log/slog"
+	"time"
+)
+
+// ReconcileLedger сверяет балансы монет и опыта пользователя userID (0 - всех) с журналом.
+// Если repair не пустой, каждое расхождение исправляется (см. QuestRepository.RepairLedgerDiscrepancy);
+// ошибка исправления одного расхождения записывается в отчет и не останавливает остальные.
+func (s *QuestService) ReconcileLedger(ctx context.Context, userID int, repair string) (*models.LedgerReconciliationReport, error) {
+	if err := models.ValidateLedgerRepair(repair); err != nil {
+		return nil, err
+	}
+
+	report := &models.LedgerReconciliationReport{CheckedAt: time.Now(), Repair: repair}
+
+	discrepancies, err := s.questRepo.GetLedgerDiscrepancies(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	report.Discrepancies = discrepancies
+
+	if repair == "" {
+		return report, nil
+	}
+
+	for i := range report.Discrepancies {
+		d := &report.Discrepancies[i]
+		repaired, err := s.questRepo.RepairLedgerDiscrepancy(ctx, d.UserID, d.Kind, repair)
+		if err != nil {
+			slog.Error("ledger repair failed", "user_id", d.UserID, "kind", d.Kind, "error", err)
+			d.Error = err.Error()
+			continue
+		}
+		if repaired != nil {
+			*d = *repaired
+		}
+	}
+
+	return report, nil
+}
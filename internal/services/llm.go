@@ -141,7 +141,11 @@ func (s *QuestService) GenerateAIQuest(userMessage string) (*models.AIQuestRespo
 				"category": "health/willpower/intelligence/creativity/social",
 				"base_xp_reward": 10-50,
 				"base_coin_reward": 5-25,
-				"task_order": 1
+				"task_order": 1,
+				"type": "special/daily/weekly",
+				"required_occurrences": 1,
+				"target_value": null,
+				"unit": null
 			}
 		]
 	}
@@ -153,6 +157,9 @@ func (s *QuestService) GenerateAIQuest(userMessage string) (*models.AIQuestRespo
 	- time_limit_hours: 24-168 (1-7 дней)
 	- reward_xp = сумма base_xp_reward всех задач * 1.5
 	- reward_coin = сумма base_coin_reward всех задач * 1.5
+	- type: "special" - разовая задача, "daily"/"weekly" - привычка, которую нужно повторять каждый день/неделю
+	- required_occurrences: для daily/weekly - сколько дней/недель нужно выполнить задачу, для special всегда 1
+	- target_value и unit: для измеримых задач - цель и единица измерения (например, 10000 и "шаги"), иначе null
 	`
 
 	answer, err := requestAI(userMessage, systemPrompt, aiModel)
@@ -0,0 +1,55 @@
+package services
+
+import (
+	"BecomeOverMan/internal/models"
+	"BecomeOverMan/internal/repositories"
+	"context"
+)
+
+var (
+	ErrNotModerator         = repositories.ErrNotModerator
+	ErrQuestNotSubmittable  = repositories.ErrQuestNotSubmittable
+	ErrQuestNotSubmitted    = repositories.ErrQuestNotSubmitted
+	ErrReportNotFound       = repositories.ErrReportNotFound
+	ErrReportedUserNotFound = repositories.ErrReportedUserNotFound
+	ErrReportSelf           = repositories.ErrReportSelf
+)
+
+// IsModerator - может ли пользователь проверять квесты и жалобы
+func (s *QuestService) IsModerator(ctx context.Context, userID int) (bool, error) {
+	return s.questRepo.IsModerator(ctx, userID)
+}
+
+// SubmitQuest отправляет квест автора на проверку модератору
+func (s *QuestService) SubmitQuest(ctx context.Context, userID, questID int) error {
+	return s.questRepo.SubmitQuest(ctx, questID, userID)
+}
+
+// GetModerationQueue возвращает квесты, ожидающие проверки
+func (s *QuestService) GetModerationQueue(ctx context.Context) ([]models.Quest, error) {
+	return s.questRepo.GetModerationQueue(ctx)
+}
+
+// ReviewQuest одобряет или отклоняет квест. Возвращает проверенный квест.
+func (s *QuestService) ReviewQuest(ctx context.Context, moderatorID, questID int, req models.ReviewQuestRequest) (*models.Quest, error) {
+	approve := req.Status == models.QuestModerationApproved
+	return s.questRepo.ReviewQuest(ctx, questID, moderatorID, approve, req.Reason)
+}
+
+// CreateReport сохраняет жалобу на квест или пользователя
+func (s *QuestService) CreateReport(ctx context.Context, reporterID int, req models.CreateReportRequest) (*models.Report, error) {
+	return s.questRepo.CreateReport(ctx, reporterID, req)
+}
+
+// GetReports возвращает жалобы с указанным статусом (по умолчанию открытые)
+func (s *QuestService) GetReports(ctx context.Context, status string) ([]models.Report, error) {
+	if status == "" {
+		status = models.ReportOpen
+	}
+	return s.questRepo.GetReports(ctx, status)
+}
+
+// ResolveReport закрывает жалобу решением модератора
+func (s *QuestService) ResolveReport(ctx context.Context, moderatorID, reportID int, req models.ResolveReportRequest) (*models.Report, error) {
+	return s.questRepo.ResolveReport(ctx, reportID, moderatorID, req.Status, req.Resolution)
+}
@@ -0,0 +1,153 @@
+package services
+
+import (
+	"BecomeOverMan/internal/models"
+	"BecomeOverMan/internal/repositories"
+	"context"
+	"slices"
+)
+
+var (
+	ErrInvalidQuest      = models.ErrInvalidQuest
+	ErrQuestNotFound     = repositories.ErrQuestNotFound
+	ErrQuestInUse        = repositories.ErrQuestInUse
+	ErrQuestTaskNotFound = repositories.ErrQuestTaskNotFound
+	ErrQuestForbidden    = repositories.ErrQuestForbidden
+
+	ErrQuestVersionConflict = repositories.ErrQuestVersionConflict
+)
+
+// CreateQuest создает квест вручную от имени автора (по умолчанию приватный). Незаданные вычисляемые
+// поля заполняются по задачам, заданные - должны совпадать с формулами (см. models.ValidateQuestDefinition).
+func (s *QuestService) CreateQuest(ctx context.Context, userID int, req models.SaveQuestRequest) (int, error) {
+	req.Quest.AuthorID = &userID
+	req.Quest.ExternalKey = nil // ключ назначается автоматически, свои ключи задаются только бандлами
+	req.Quest.TemplateID = nil
+	if req.Quest.Visibility == "" {
+		req.Quest.Visibility = models.QuestVisibilityPrivate
+	}
+
+	models.ApplyQuestFormulas(req.Quest, req.Tasks)
+	if err := models.ValidateQuestDefinition(req.Quest, req.Tasks); err != nil {
+		return 0, err
+	}
+
+	return s.questRepo.SaveQuestToDB(req.Quest, req.Tasks)
+}
+
+// UpdateQuest заменяет квест и его задачи, публикуя новую версию квеста.
+// Видимость квеста меняется отдельно (SetQuestVisibility).
+func (s *QuestService) UpdateQuest(ctx context.Context, userID, questID int, req models.SaveQuestRequest) (*models.Quest, error) {
+	if err := s.questRepo.CheckQuestAuthor(ctx, questID, userID); err != nil {
+		return nil, err
+	}
+
+	// Квест заменяется целиком, поэтому публикуется поверх любой последней версии
+	models.ApplyQuestFormulas(req.Quest, req.Tasks)
+	return s.publishQuest(ctx, questID, 0, req.Quest, req.Tasks)
+}
+
+// DeleteQuest удаляет квест, если его никто не купил и не проходит
+func (s *QuestService) DeleteQuest(ctx context.Context, userID, questID int) error {
+	return s.questRepo.DeleteQuest(ctx, questID, userID)
+}
+
+// SetQuestVisibility меняет видимость квеста автора
+func (s *QuestService) SetQuestVisibility(ctx context.Context, userID, questID int, visibility string) error {
+	return s.questRepo.SetQuestVisibility(ctx, questID, userID, visibility)
+}
+
+// AddQuestTask добавляет задачу в квест на позицию task_order (в конец, если он не задан).
+// Вычисляемые поля квеста пересчитываются, публикуется новая версия.
+func (s *QuestService) AddQuestTask(ctx context.Context, userID, questID int, task models.Task) (*models.Quest, error) {
+	quest, err := s.authoredQuest(ctx, userID, questID)
+	if err != nil {
+		return nil, err
+	}
+
+	tasks := placeQuestTask(quest.Tasks, task)
+	return s.republishQuest(ctx, quest, tasks)
+}
+
+// UpdateQuestTask заменяет задачу квеста. Если task_order не задан, задача остается на своем месте.
+func (s *QuestService) UpdateQuestTask(ctx context.Context, userID, questID, taskID int, task models.Task) (*models.Quest, error) {
+	quest, err := s.authoredQuest(ctx, userID, questID)
+	if err != nil {
+		return nil, err
+	}
+
+	i := slices.IndexFunc(quest.Tasks, func(t models.Task) bool { return t.ID == taskID })
+	if i < 0 {
+		return nil, ErrQuestTaskNotFound
+	}
+
+	if task.TaskOrder == 0 {
+		task.TaskOrder = quest.Tasks[i].TaskOrder
+	}
+	tasks := placeQuestTask(slices.Delete(quest.Tasks, i, i+1), task)
+	return s.republishQuest(ctx, quest, tasks)
+}
+
+// DeleteQuestTask убирает задачу из квеста, оставшиеся задачи перенумеровываются
+func (s *QuestService) DeleteQuestTask(ctx context.Context, userID, questID, taskID int) (*models.Quest, error) {
+	quest, err := s.authoredQuest(ctx, userID, questID)
+	if err != nil {
+		return nil, err
+	}
+
+	i := slices.IndexFunc(quest.Tasks, func(t models.Task) bool { return t.ID == taskID })
+	if i < 0 {
+		return nil, ErrQuestTaskNotFound
+	}
+
+	tasks := slices.Delete(quest.Tasks, i, i+1)
+	renumberQuestTasks(tasks)
+	return s.republishQuest(ctx, quest, tasks)
+}
+
+// authoredQuest возвращает последнюю версию квеста, если пользователь - его автор
+func (s *QuestService) authoredQuest(ctx context.Context, userID, questID int) (*models.Quest, error) {
+	if err := s.questRepo.CheckQuestAuthor(ctx, questID, userID); err != nil {
+		return nil, err
+	}
+	return s.questRepo.GetQuestDefinition(ctx, questID)
+}
+
+// republishQuest пересчитывает вычисляемые поля квеста по новому набору задач и публикует версию.
+// Набор задач собран из прочитанной версии quest.Version, поэтому если квест успели изменить
+// параллельно, публикация отклоняется с ErrQuestVersionConflict (иначе одно из изменений потерялось бы).
+func (s *QuestService) republishQuest(ctx context.Context, quest *models.Quest, tasks []models.Task) (*models.Quest, error) {
+	models.ResetQuestFormulas(quest)
+	models.ApplyQuestFormulas(quest, tasks)
+	return s.publishQuest(ctx, quest.ID, quest.Version, quest, tasks)
+}
+
+func (s *QuestService) publishQuest(ctx context.Context, questID, expectedVersion int, quest *models.Quest, tasks []models.Task) (*models.Quest, error) {
+	if err := models.ValidateQuestDefinition(quest, tasks); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.questRepo.PublishQuestVersion(ctx, questID, expectedVersion, quest, tasks); err != nil {
+		return nil, err
+	}
+
+	return s.questRepo.GetQuestDefinition(ctx, questID)
+}
+
+// placeQuestTask вставляет задачу на позицию task_order (в конец, если позиция не задана или вне списка)
+func placeQuestTask(tasks []models.Task, task models.Task) []models.Task {
+	pos := len(tasks)
+	if task.TaskOrder >= 1 && task.TaskOrder <= len(tasks) {
+		pos = task.TaskOrder - 1
+	}
+
+	tasks = slices.Insert(tasks, pos, task)
+	renumberQuestTasks(tasks)
+	return tasks
+}
+
+func renumberQuestTasks(tasks []models.Task) {
+	for i := range tasks {
+		tasks[i].TaskOrder = i + 1
+	}
+}
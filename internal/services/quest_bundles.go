@@ -0,0 +1,150 @@
+package services
+
+import (
+	"BecomeOverMan/internal/models"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var ErrBundleKeyOfUserQuest = errors.New("external_key belongs to a user quest")
+
+// ExportQuestBundle выгружает квесты (по умолчанию - все системные) в бандл
+func (s *QuestService) ExportQuestBundle(ctx context.Context, questIDs []int) (*models.QuestBundle, error) {
+	quests, err := s.questRepo.GetQuestDefinitions(ctx, questIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	bundle := &models.QuestBundle{
+		FormatVersion: models.QuestBundleFormatVersion,
+		Quests:        make([]models.BundleQuest, 0, len(quests)),
+	}
+	for _, q := range quests {
+		bq, err := models.NewBundleQuest(&q, q.Tasks)
+		if err != nil {
+			return nil, fmt.Errorf("quest %d: %w", q.ID, err)
+		}
+		bundle.Quests = append(bundle.Quests, bq)
+	}
+
+	return bundle, nil
+}
+
+// ImportQuestBundle загружает квесты бандла как системные публичные квесты. Квест сопоставляется
+// с существующим по external_key: новый квест создается, измененный получает новую версию,
+// совпадающий с последней версией не меняется. Каждый квест импортируется в своей транзакции,
+// ошибка одного квеста попадает в его строку результата и не мешает остальным.
+// Вторым значением возвращаются созданные и обновленные квесты.
+func (s *QuestService) ImportQuestBundle(ctx context.Context, bundle *models.QuestBundle) (*models.BundleImportResult, []*models.Quest, error) {
+	// Ключ, повторяющийся в бандле, неоднозначен - такие квесты не импортируются
+	keyCount := make(map[string]int, len(bundle.Quests))
+	for _, bq := range bundle.Quests {
+		keyCount[strings.TrimSpace(bq.ExternalKey)]++
+	}
+
+	result := &models.BundleImportResult{Rows: make([]models.BundleRowResult, 0, len(bundle.Quests))}
+	var changed []*models.Quest
+	for i, bq := range bundle.Quests {
+		key := strings.TrimSpace(bq.ExternalKey)
+		row := models.BundleRowResult{Index: i, ExternalKey: key}
+
+		var quest *models.Quest
+		var err error
+		if key != "" && keyCount[key] > 1 {
+			err = fmt.Errorf("%w: external_key %q is used by several quests of the bundle", ErrInvalidQuest, key)
+		} else {
+			row.Action, quest, err = s.importBundleQuest(ctx, bq)
+		}
+		if err != nil {
+			if !errors.Is(err, ErrInvalidQuest) && !errors.Is(err, ErrBundleKeyOfUserQuest) {
+				// Ошибка БД прерывает импорт: уже импортированные квесты остаются, результат возвращается
+				return result, changed, fmt.Errorf("quest %q (index %d): %w", key, i, err)
+			}
+			row.Action = models.BundleRowError
+			row.Error = err.Error()
+			result.Failed++
+			result.Rows = append(result.Rows, row)
+			continue
+		}
+
+		row.QuestID = quest.ID
+		row.Version = quest.Version
+		switch row.Action {
+		case models.BundleRowCreated:
+			result.Created++
+			changed = append(changed, quest)
+		case models.BundleRowUpdated:
+			result.Updated++
+			changed = append(changed, quest)
+		case models.BundleRowUnchanged:
+			result.Unchanged++
+		}
+		result.Rows = append(result.Rows, row)
+	}
+
+	return result, changed, nil
+}
+
+// importBundleQuest создает / обновляет квест с тем же external_key. Квест, совпадающий с последней
+// версией, не проверяется формулами: системные квесты из seed-данных могут им не соответствовать,
+// и повторный импорт выгруженного бандла не должен на них падать. Новый и измененный квест
+// проверяются как при ручном создании, формулы заполняют только не заданные в бандле поля.
+func (s *QuestService) importBundleQuest(ctx context.Context, bq models.BundleQuest) (string, *models.Quest, error) {
+	quest, tasks, err := bq.ToQuest()
+	if err != nil {
+		return "", nil, err
+	}
+	if *quest.ExternalKey == "" {
+		return "", nil, fmt.Errorf("%w: external_key is required", ErrInvalidQuest)
+	}
+
+	existing, err := s.questRepo.GetQuestByExternalKey(ctx, *quest.ExternalKey)
+	if errors.Is(err, ErrQuestNotFound) {
+		models.ApplyQuestFormulas(quest, tasks)
+		if err := models.ValidateQuestDefinition(quest, tasks); err != nil {
+			return "", nil, err
+		}
+
+		quest.ID, err = s.questRepo.SaveQuestToDB(quest, tasks)
+		if err != nil {
+			return "", nil, err
+		}
+		quest.Version = 1
+		quest.Tasks = tasks
+		return models.BundleRowCreated, quest, nil
+	}
+	if err != nil {
+		return "", nil, err
+	}
+	if existing.AuthorID != nil {
+		return "", nil, ErrBundleKeyOfUserQuest
+	}
+
+	// Повторный импорт того же квеста не создает новую версию
+	incoming, err := models.NewBundleQuest(quest, tasks)
+	if err != nil {
+		return "", nil, err
+	}
+	current, err := models.NewBundleQuest(existing, existing.Tasks)
+	if err != nil {
+		return "", nil, err
+	}
+	if models.SameBundleQuest(incoming, current) {
+		return models.BundleRowUnchanged, existing, nil
+	}
+
+	models.ApplyQuestFormulas(quest, tasks)
+	if err := models.ValidateQuestDefinition(quest, tasks); err != nil {
+		return "", nil, err
+	}
+
+	quest.ID = existing.ID
+	quest.Version, err = s.questRepo.PublishQuestVersion(ctx, existing.ID, existing.Version, quest, tasks)
+	if err != nil {
+		return "", nil, err
+	}
+	quest.Tasks = tasks
+	return models.BundleRowUpdated, quest, nil
+}
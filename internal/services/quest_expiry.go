@@ -0,0 +1,86 @@
+package services
+
+import (
+	"BecomeOverMan/internal/repositories"
+	"context"
+	"log/slog"
+	"time"
+)
+
+var ErrQuestExpired = repositories.ErrQuestExpired
+
+// expiryBatchSize - сколько просроченных квестов обрабатывается за одну итерацию воркера
+const expiryBatchSize = 100
+
+// RunQuestExpiryWorker периодически переводит просроченные квесты в статус failed,
+// задачи с истекшим дедлайном - в статус missed, а прерванные серии обнуляет.
+// Блокируется до отмены ctx, поэтому запускается в отдельной горутине.
+func (s *QuestService) RunQuestExpiryWorker(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	slog.Info("Quest expiry worker started", "interval", interval)
+
+	for {
+		s.failExpiredQuests(ctx)
+		s.markMissedTasks(ctx)
+		s.resetLapsedStreaks(ctx)
+
+		select {
+		case <-ctx.Done():
+			slog.Info("Quest expiry worker stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// failExpiredQuests обрабатывает все просроченные квесты пачками по expiryBatchSize
+func (s *QuestService) failExpiredQuests(ctx context.Context) {
+	for {
+		expired, err := s.questRepo.FailExpiredQuests(ctx, expiryBatchSize)
+		if err != nil {
+			slog.Error("Failed to fail expired quests", "error", err)
+			return
+		}
+
+		for _, e := range expired {
+			slog.Info("Quest expired", "user_id", e.UserID, "quest_id", e.QuestID, "expires_at", e.ExpiresAt)
+		}
+
+		if len(expired) < expiryBatchSize {
+			return
+		}
+	}
+}
+
+// markMissedTasks отмечает задачи с истекшим дедлайном как missed
+// и проваливает квесты, которые из-за этого уже не завершить
+func (s *QuestService) markMissedTasks(ctx context.Context) {
+	missed, failed, err := s.questRepo.MarkMissedTasks(ctx)
+	if err != nil {
+		slog.Error("Failed to mark missed tasks", "error", err)
+		return
+	}
+
+	if missed > 0 {
+		slog.Info("Tasks marked as missed", "count", missed)
+	}
+
+	for _, f := range failed {
+		slog.Info("Quest failed: task deadline missed", "user_id", f.UserID, "quest_id", f.QuestID)
+	}
+}
+
+// resetLapsedStreaks обнуляет серии пользователей, пропустивших день без заморозки
+func (s *QuestService) resetLapsedStreaks(ctx context.Context) {
+	reset, err := s.questRepo.ResetLapsedStreaks(ctx)
+	if err != nil {
+		slog.Error("Failed to reset lapsed streaks", "error", err)
+		return
+	}
+
+	if reset > 0 {
+		slog.Info("Lapsed streaks reset", "count", reset)
+	}
+}
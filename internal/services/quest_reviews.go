@@ -0,0 +1,27 @@
+package services
+
+import (
+	"BecomeOverMan/internal/models"
+	"BecomeOverMan/internal/repositories"
+	"context"
+)
+
+var (
+	ErrQuestNotCompleted   = repositories.ErrQuestNotCompleted
+	ErrQuestReviewNotFound = repositories.ErrQuestReviewNotFound
+)
+
+// RateQuest сохраняет оценку и отзыв пользователя о завершенном квесте
+func (s *QuestService) RateQuest(ctx context.Context, userID, questID int, req models.RateQuestRequest) (*models.QuestReview, error) {
+	return s.questRepo.UpsertQuestReview(ctx, userID, questID, req.Rating, req.Review)
+}
+
+// DeleteQuestReview удаляет отзыв пользователя о квесте
+func (s *QuestService) DeleteQuestReview(ctx context.Context, userID, questID int) error {
+	return s.questRepo.DeleteQuestReview(ctx, userID, questID)
+}
+
+// GetQuestReviews возвращает отзывы о квесте
+func (s *QuestService) GetQuestReviews(ctx context.Context, questID, userID int) ([]models.QuestReview, error) {
+	return s.questRepo.GetQuestReviews(ctx, questID, userID)
+}
@@ -1,6 +1,7 @@
 package services
 
 import (
+	"BecomeOverMan/internal/config"
 	"BecomeOverMan/internal/integrations"
 	"BecomeOverMan/internal/models"
 	"BecomeOverMan/internal/repositories"
@@ -14,16 +15,37 @@ import (
 	"time"
 )
 
+var (
+	ErrTaskLocked          = repositories.ErrTaskLocked
+	ErrTaskOnCooldown      = repositories.ErrTaskOnCooldown
+	ErrTaskPeriodCompleted = repositories.ErrTaskPeriodCompleted
+	ErrTaskVariantRequired = repositories.ErrTaskVariantRequired
+	ErrTaskVariantNotFound = repositories.ErrTaskVariantNotFound
+
+	ErrTaskUndoExpired        = repositories.ErrTaskUndoExpired
+	ErrTaskUndoNotEnoughCoins = repositories.ErrTaskUndoNotEnoughCoins
+	ErrTaskUndoLocked         = repositories.ErrTaskUndoLocked
+
+	ErrQuestNotRefundable = repositories.ErrQuestNotRefundable
+	ErrQuestRefundExpired = repositories.ErrQuestRefundExpired
+	ErrQuestNotApproved   = repositories.ErrQuestNotApproved
+
+	ErrTaskOutsideWindow  = repositories.ErrTaskOutsideWindow
+	ErrTaskDeadlineMissed = repositories.ErrTaskDeadlineMissed
+)
+
 type QuestService struct {
-	questRepo *repositories.QuestRepository
-	userRepo  *repositories.UserRepository
+	questRepo   *repositories.QuestRepository
+	userRepo    *repositories.UserRepository
+	fileStorage integrations.FileStorage
 }
 
 func NewQuestService(
 	questRepo *repositories.QuestRepository,
 	userRepo *repositories.UserRepository,
+	fileStorage integrations.FileStorage,
 ) *QuestService {
-	return &QuestService{questRepo: questRepo, userRepo: userRepo}
+	return &QuestService{questRepo: questRepo, userRepo: userRepo, fileStorage: fileStorage}
 }
 
 // GetAvailableQuests returns quests available for the user
@@ -31,8 +53,8 @@ func (s *QuestService) GetAvailableQuests(ctx context.Context, userID int) ([]mo
 	return s.questRepo.GetAvailableQuests(ctx, userID)
 }
 
-func (s *QuestService) GetQuestShop(ctx context.Context, userID int) ([]models.Quest, error) {
-	return s.questRepo.GetQuestShop(ctx, userID)
+func (s *QuestService) GetQuestShop(ctx context.Context, userID int, sortBy string) ([]models.Quest, error) {
+	return s.questRepo.GetQuestShop(ctx, userID, sortBy)
 }
 
 func (s *QuestService) GetMyActiveQuests(ctx context.Context, userID int) ([]models.Quest, error) {
@@ -55,35 +77,38 @@ func (s *QuestService) PurchaseQuest(ctx context.Context, userID, questID int) e
 		return err
 	}
 
-	go func() {
-		questIDS, err := s.getUserQuestIDs(userID)
-		if err != nil {
-			slog.Error("Failed to get user quest IDs", "error", err, "user_id", userID)
-			return
-		}
+	go s.syncUserQuestsWithRecommendationService(userID)
 
-		if len(questIDS) == 0 {
-			slog.Info("User has no quests", "user_id", userID)
-		}
+	return nil
+}
 
-		req := models.RecommendationService_AddUsers_Request{
-			Users: []models.UserWithQuestIDS{
-				{
-					UserID:   userID,
-					QuestIDs: questIDS,
-				},
-			},
-		}
+// syncUserQuestsWithRecommendationService sends the current list of the user's quests to the recommendation service
+func (s *QuestService) syncUserQuestsWithRecommendationService(userID int) {
+	questIDS, err := s.getUserQuestIDs(userID)
+	if err != nil {
+		slog.Error("Failed to get user quest IDs", "error", err, "user_id", userID)
+		return
+	}
 
-		response, err := s.sendUserQuestToRecommendationService(req)
-		if err != nil {
-			slog.Error("Failed to send user quest to recommendation service", "error", err, "user_id", userID)
-		}
+	if len(questIDS) == 0 {
+		slog.Info("User has no quests", "user_id", userID)
+	}
 
-		slog.Info("User quest sent to recommendation service", "user_id", userID, "response", response)
-	}()
+	req := models.RecommendationService_AddUsers_Request{
+		Users: []models.UserWithQuestIDS{
+			{
+				UserID:   userID,
+				QuestIDs: questIDS,
+			},
+		},
+	}
 
-	return nil
+	response, err := s.sendUserQuestToRecommendationService(req)
+	if err != nil {
+		slog.Error("Failed to send user quest to recommendation service", "error", err, "user_id", userID)
+	}
+
+	slog.Info("User quest sent to recommendation service", "user_id", userID, "response", response)
 }
 
 func (s *QuestService) getUserQuestIDs(userID int) ([]int, error) {
@@ -128,16 +153,54 @@ func (s *QuestService) sendUserQuestToRecommendationService(req models.Recommend
 	return response, nil
 }
 
+// RetryQuest moves a failed quest back to purchased, charging the configured retry price
+func (s *QuestService) RetryQuest(ctx context.Context, userID, questID int) error {
+	return s.questRepo.RetryQuest(ctx, userID, questID, config.Cfg.QuestRetryPricePercent)
+}
+
+// RefundQuest returns the price of a purchased quest that was never started
+func (s *QuestService) RefundQuest(ctx context.Context, userID, questID int) error {
+	refunded, err := s.questRepo.RefundQuest(ctx, userID, questID, config.Cfg.QuestRefundWindow)
+	if err != nil {
+		return err
+	}
+	slog.Info("Quest refunded", "user_id", userID, "quest_id", questID, "amount", refunded)
+
+	go s.syncUserQuestsWithRecommendationService(userID)
+
+	return nil
+}
+
+// GetQuestAttempts returns the history of previous attempts of the quest
+func (s *QuestService) GetQuestAttempts(ctx context.Context, userID, questID int) ([]models.QuestAttempt, error) {
+	return s.questRepo.GetQuestAttempts(ctx, userID, questID)
+}
+
+// GetActiveEffects returns effects (multipliers, streak freezes) currently active for the user
+func (s *QuestService) GetActiveEffects(ctx context.Context, userID int) ([]models.ActiveEffect, error) {
+	return s.questRepo.GetActiveEffects(ctx, userID)
+}
+
 // StartQuest begins the execution of a purchased quest
 func (s *QuestService) StartQuest(ctx context.Context, userID, questID int) error {
 	return s.questRepo.StartQuest(ctx, userID, questID)
 }
 
 // CompleteTask marks a task as completed by the user
-func (s *QuestService) CompleteTask(ctx context.Context, userID, questID, taskID int) error {
+func (s *QuestService) CompleteTask(ctx context.Context, userID, questID, taskID int) (string, error) {
 	return s.questRepo.CompleteTask(ctx, userID, questID, taskID)
 }
 
+// UndoTaskCompletion reverts a recently completed task back to active and takes its reward back
+func (s *QuestService) UndoTaskCompletion(ctx context.Context, userID, questID, taskID int) error {
+	return s.questRepo.UndoTaskCompletion(ctx, userID, questID, taskID, config.Cfg.TaskUndoWindow)
+}
+
+// ChooseTaskVariant saves the variant the user picked for a quest step
+func (s *QuestService) ChooseTaskVariant(ctx context.Context, userID, questID, taskID, variantID int) error {
+	return s.questRepo.ChooseTaskVariant(ctx, userID, questID, taskID, variantID)
+}
+
 // CompleteQuest finalizes the quest completion
 func (s *QuestService) CompleteQuest(ctx context.Context, userID, questID int) error {
 	return s.questRepo.CompleteQuest(ctx, userID, questID)
@@ -147,6 +210,11 @@ func (s *QuestService) GetQuestDetails(ctx context.Context, questID int, userID
 	return s.questRepo.GetQuestDetails(ctx, questID, userID)
 }
 
+// GetQuestVersions returns the version history of a quest with the changes between versions
+func (s *QuestService) GetQuestVersions(ctx context.Context, questID, userID int) ([]models.QuestVersion, error) {
+	return s.questRepo.GetQuestVersions(ctx, questID, userID)
+}
+
 func (s *QuestService) CreateSharedQuest(user1ID, user2ID, questID int) error {
 	return s.questRepo.CreateSharedQuest(user1ID, user2ID, questID)
 }
@@ -159,6 +227,7 @@ func (s *QuestService) SearchQuests(
 	ctx context.Context,
 	req models.RecommendationService_SearchQuest_Request,
 	userID int,
+	sortBy string,
 ) (models.SearchQuestsResponse, error) {
 	// 1. Создаем URL
 	url := integrations.Recommendation_Service_BASE_URL + "/quests/search"
@@ -202,8 +271,9 @@ func (s *QuestService) SearchQuests(
 
 	var questsWithDetails []models.Quest
 
-	// 8. Достаем квесты с деталями из БД (тут сразу и те что есть у юзера и те что еще не куплены)
-	questsWithDetails, err = s.questRepo.SearchQuestsWithDetailsByIDs(ctx, questsIDS)
+	// 8. Достаем квесты с деталями из БД (тут сразу и те что есть у юзера и те что еще не куплены).
+	// Квесты, не видимые пользователю (чужие приватные и т.п.), отбрасываются
+	questsWithDetails, err = s.questRepo.SearchQuestsWithDetailsByIDs(ctx, questsIDS, userID)
 	if err != nil {
 		slog.ErrorContext(ctx, "ошибка получения квестов из БД с указанными ids во время поиска",
 			"error", err,
@@ -214,6 +284,9 @@ func (s *QuestService) SearchQuests(
 
 	// 9. Возвращаем результат = []struct{questWithDetails, SimilaryScore}
 	questsWithDetailsAndSimilarityResponse := models.NewSearchQuestsResponse(questsWithDetails, response)
+	if sortBy == models.QuestSortRating {
+		models.SortSearchResultsByRating(questsWithDetailsAndSimilarityResponse)
+	}
 
 	return questsWithDetailsAndSimilarityResponse, nil
 }
@@ -321,7 +394,27 @@ func (s *QuestService) RecommendQuests(ctx context.Context, userID int) (*models
 		UserQuestIDs: questIDS,
 	}
 
-	return s.recommendQuests(ctx, req)
+	resp, err := s.recommendQuests(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	// Убираем квесты, не видимые пользователю (чужие приватные и т.п.)
+	recommendedIDs := make([]int, len(resp.Recommendations))
+	for i, rec := range resp.Recommendations {
+		recommendedIDs[i] = rec.ID
+	}
+
+	visibleIDs, err := s.questRepo.VisibleQuestIDs(ctx, recommendedIDs, userID)
+	if err != nil {
+		return nil, fmt.Errorf("error filtering visible quests (while recommend quests): %w", err)
+	}
+
+	resp.Recommendations = slices.DeleteFunc(resp.Recommendations, func(rec models.RecommendQuests_Result) bool {
+		return !slices.Contains(visibleIDs, rec.ID)
+	})
+
+	return resp, nil
 }
 
 func (s *QuestService) recommendQuests(ctx context.Context, req models.RecommendationService_RecommendQuests_Req) (*models.RecommendationService_RecommendQuests_Resp, error) {
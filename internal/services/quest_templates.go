@@ -0,0 +1,89 @@
+package services
+
+import (
+	"BecomeOverMan/internal/models"
+	"BecomeOverMan/internal/repositories"
+	"context"
+	"encoding/json"
+)
+
+var (
+	ErrQuestTemplateNotFound = repositories.ErrQuestTemplateNotFound
+	ErrInvalidTemplate       = models.ErrInvalidTemplate
+	ErrInvalidTemplateParams = models.ErrInvalidTemplateParams
+)
+
+// CreateQuestTemplate проверяет и сохраняет шаблон квеста
+func (s *QuestService) CreateQuestTemplate(ctx context.Context, userID int, req models.CreateQuestTemplateRequest) (*models.QuestTemplate, error) {
+	if req.Parameters == nil {
+		req.Parameters = []models.TemplateParam{}
+	}
+	if err := models.ValidateQuestTemplate(req.Parameters, req.Quest); err != nil {
+		return nil, err
+	}
+
+	params, err := json.Marshal(req.Parameters)
+	if err != nil {
+		return nil, err
+	}
+	quest, err := json.Marshal(req.Quest)
+	if err != nil {
+		return nil, err
+	}
+
+	tmpl := &models.QuestTemplate{
+		Title:          req.Title,
+		Description:    req.Description,
+		CreatedBy:      &userID,
+		ParametersJson: params,
+		QuestJson:      quest,
+	}
+	if err := s.questRepo.CreateQuestTemplate(ctx, tmpl); err != nil {
+		return nil, err
+	}
+
+	tmpl.Parameters = req.Parameters
+	tmpl.Quest = req.Quest
+	return tmpl, nil
+}
+
+func (s *QuestService) GetQuestTemplates(ctx context.Context) ([]models.QuestTemplate, error) {
+	return s.questRepo.GetQuestTemplates(ctx)
+}
+
+func (s *QuestService) GetQuestTemplate(ctx context.Context, templateID int) (*models.QuestTemplate, error) {
+	return s.questRepo.GetQuestTemplate(ctx, templateID)
+}
+
+func (s *QuestService) DeleteQuestTemplate(ctx context.Context, templateID int) error {
+	return s.questRepo.DeleteQuestTemplate(ctx, templateID)
+}
+
+// InstantiateQuestTemplate создает по шаблону личный квест пользователя: подставляет параметры,
+// считает награды и цену по формулам и сохраняет квест как обычный пользовательский (черновик, private).
+func (s *QuestService) InstantiateQuestTemplate(ctx context.Context, userID, templateID int, params map[string]any) (*models.Quest, error) {
+	tmpl, err := s.questRepo.GetQuestTemplate(ctx, templateID)
+	if err != nil {
+		return nil, err
+	}
+
+	quest, tasks, err := tmpl.Instantiate(params)
+	if err != nil {
+		return nil, err
+	}
+	quest.AuthorID = &userID
+	quest.Visibility = models.QuestVisibilityPrivate
+	quest.TemplateID = &tmpl.ID
+
+	models.ApplyQuestFormulas(quest, tasks)
+	if err := models.ValidateQuestDefinition(quest, tasks); err != nil {
+		return nil, err
+	}
+
+	questID, err := s.questRepo.SaveQuestToDB(quest, tasks)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.questRepo.GetQuestDefinition(ctx, questID)
+}
@@ -0,0 +1,35 @@
+package services
+
+import (
+	"BecomeOverMan/internal/models"
+	"BecomeOverMan/internal/repositories"
+	"context"
+)
+
+var (
+	ErrShopItemNotFound       = repositories.ErrShopItemNotFound
+	ErrItemNotInInventory     = repositories.ErrItemNotInInventory
+	ErrItemQuestNotExtendable = repositories.ErrItemQuestNotExtendable
+	ErrItemQuestRequired      = models.ErrItemQuestRequired
+)
+
+func (s *QuestService) GetShopItems(ctx context.Context) ([]models.ShopItem, error) {
+	return s.questRepo.GetShopItems(ctx)
+}
+
+func (s *QuestService) GetInventory(ctx context.Context, userID int) ([]models.InventoryItem, error) {
+	return s.questRepo.GetInventory(ctx, userID)
+}
+
+// PurchaseShopItem покупает предметы за монеты (по умолчанию один)
+func (s *QuestService) PurchaseShopItem(ctx context.Context, userID, itemID int, req models.PurchaseItemRequest) (*models.InventoryItem, error) {
+	if req.Quantity <= 0 {
+		req.Quantity = 1
+	}
+	return s.questRepo.PurchaseShopItem(ctx, userID, itemID, req.Quantity)
+}
+
+// UseItem использует предмет из инвентаря
+func (s *QuestService) UseItem(ctx context.Context, userID, itemID int, req models.UseItemRequest) (*models.InventoryItem, error) {
+	return s.questRepo.UseItem(ctx, userID, itemID, req.QuestID)
+}
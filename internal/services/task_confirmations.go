@@ -0,0 +1,29 @@
+package services
+
+import (
+	"BecomeOverMan/internal/models"
+	"BecomeOverMan/internal/repositories"
+	"context"
+)
+
+var (
+	ErrConfirmerRequired        = repositories.ErrConfirmerRequired
+	ErrConfirmerNotFriend       = repositories.ErrConfirmerNotFriend
+	ErrTaskConfirmationNotFound = repositories.ErrTaskConfirmationNotFound
+)
+
+// SetQuestConfirmer назначает друга, подтверждающего выполнение задач квеста
+func (s *QuestService) SetQuestConfirmer(ctx context.Context, userID, questID, friendID int) error {
+	return s.questRepo.SetQuestConfirmer(ctx, userID, questID, friendID)
+}
+
+// GetPendingConfirmations возвращает задачи друзей, ожидающие подтверждения от пользователя
+func (s *QuestService) GetPendingConfirmations(ctx context.Context, userID int) ([]models.TaskConfirmation, error) {
+	return s.questRepo.GetPendingConfirmations(ctx, userID)
+}
+
+// DecideTaskConfirmation одобряет или отклоняет выполнение задачи друга
+func (s *QuestService) DecideTaskConfirmation(ctx context.Context, userID, confirmationID int, req models.DecideTaskConfirmationRequest) error {
+	approve := req.Status == models.ConfirmationApproved
+	return s.questRepo.DecideTaskConfirmation(ctx, userID, confirmationID, approve, req.Reason)
+}
@@ -0,0 +1,34 @@
+package services
+
+import (
+	"BecomeOverMan/internal/models"
+	"BecomeOverMan/internal/repositories"
+	"context"
+)
+
+var (
+	ErrTaskNotQuantitative  = repositories.ErrTaskNotQuantitative
+	ErrTaskTargetNotReached = repositories.ErrTaskTargetNotReached
+)
+
+// AddTaskProgress записывает прогресс по количественной задаче.
+// Когда цель достигнута, задача выполняется через CompleteTask (с наградой, доказательствами и подтверждением другом).
+// Если выполнить задачу сейчас нельзя, прогресс все равно сохраняется и возвращается вместе с ошибкой.
+func (s *QuestService) AddTaskProgress(ctx context.Context, userID, questID, taskID int, value float64) (*models.TaskProgress, error) {
+	progress, err := s.questRepo.AddTaskProgress(ctx, userID, questID, taskID, value)
+	if err != nil {
+		return nil, err
+	}
+
+	if !progress.TargetReached() {
+		return progress, nil
+	}
+
+	status, err := s.questRepo.CompleteTask(ctx, userID, questID, taskID)
+	if err != nil {
+		return progress, err
+	}
+	progress.Status = status
+
+	return progress, nil
+}
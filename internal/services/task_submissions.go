@@ -0,0 +1,104 @@
+package services
+
+import (
+	"BecomeOverMan/internal/models"
+	"BecomeOverMan/internal/repositories"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+var (
+	ErrProofRequired          = repositories.ErrProofRequired
+	ErrTaskSubmissionNotFound = repositories.ErrTaskSubmissionNotFound
+	ErrSubmissionTaskNotFound = repositories.ErrSubmissionTaskNotFound
+	ErrInvalidProof           = errors.New("invalid proof for the task")
+)
+
+// SubmitTaskProof сохраняет доказательство выполнения задачи.
+// Тип доказательства должен совпадать с proof_type задачи, файлы складываются в fileStorage.
+func (s *QuestService) SubmitTaskProof(
+	ctx context.Context,
+	userID, questID, taskID int,
+	req models.SubmitTaskProofRequest,
+	file *models.TaskProofFile,
+) (*models.TaskSubmission, error) {
+	proofType, err := s.questRepo.GetTaskProofType(ctx, userID, questID, taskID)
+	if err != nil {
+		return nil, err
+	}
+
+	submission := models.TaskSubmission{
+		UserID:    userID,
+		QuestID:   questID,
+		TaskID:    taskID,
+		ProofType: proofType,
+		TextValue: req.Text,
+	}
+
+	switch proofType {
+	case models.ProofTypeNone:
+		return nil, fmt.Errorf("%w: task doesn't require a proof", ErrInvalidProof)
+	case models.ProofTypeText:
+		if req.Text == nil || strings.TrimSpace(*req.Text) == "" {
+			return nil, fmt.Errorf("%w: text is required", ErrInvalidProof)
+		}
+	case models.ProofTypeNumber:
+		if req.Value == nil {
+			return nil, fmt.Errorf("%w: value is required", ErrInvalidProof)
+		}
+		submission.NumericValue = req.Value
+	case models.ProofTypeFile:
+		if file == nil {
+			return nil, fmt.Errorf("%w: file is required", ErrInvalidProof)
+		}
+
+		fileName := filepath.Base(file.FileName)
+		key := fmt.Sprintf("submissions/%d/%d/%d/%d%s",
+			userID, questID, taskID, time.Now().UnixNano(), filepath.Ext(fileName))
+		if err := s.fileStorage.Save(ctx, key, file.Reader); err != nil {
+			return nil, fmt.Errorf("failed to store proof file: %w", err)
+		}
+
+		submission.FileKey = &key
+		submission.FileName = &fileName
+		submission.ContentType = &file.ContentType
+	}
+
+	created, err := s.questRepo.CreateTaskSubmission(ctx, submission)
+	if err != nil {
+		// Задача могла завершиться, пока загружался файл: не оставляем файл без доказательства
+		if submission.FileKey != nil {
+			if delErr := s.fileStorage.Delete(ctx, *submission.FileKey); delErr != nil {
+				slog.Error("Failed to delete orphaned proof file", "key", *submission.FileKey, "error", delErr)
+			}
+		}
+		return nil, err
+	}
+
+	return created, nil
+}
+
+// OpenTaskSubmissionFile открывает файл доказательства для его автора или подтверждающего друга
+func (s *QuestService) OpenTaskSubmissionFile(ctx context.Context, userID, submissionID int) (*models.TaskSubmission, io.ReadCloser, error) {
+	submission, err := s.questRepo.GetTaskSubmission(ctx, userID, submissionID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if submission.FileKey == nil {
+		return nil, nil, ErrTaskSubmissionNotFound
+	}
+
+	f, err := s.fileStorage.Open(ctx, *submission.FileKey)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return submission, f, nil
+}
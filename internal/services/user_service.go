@@ -1,8 +1,10 @@
 package services
 
 import (
+	"BecomeOverMan/internal/config"
 	"BecomeOverMan/internal/models"
 	"BecomeOverMan/internal/repositories"
+	"context"
 	"database/sql"
 	"errors"
 	"log"
@@ -16,6 +18,13 @@ type UserService struct {
 
 var ErrUserVersionConflict = errors.New("user version conflict")
 
+var (
+	ErrGiftToSelf            = repositories.ErrGiftToSelf
+	ErrGiftNotFriends        = repositories.ErrGiftNotFriends
+	ErrGiftDailyLimit        = repositories.ErrGiftDailyLimit
+	ErrGiftRecipientNotFound = repositories.ErrGiftRecipientNotFound
+)
+
 func NewUserService(repo *repositories.UserRepository) *UserService {
 	return &UserService{repo: repo}
 }
@@ -104,3 +113,8 @@ func (s *UserService) UpdateUser(userID int, req models.UpdateUserRequest) (mode
 func (s *UserService) DeleteUser(userID int) (bool, error) {
 	return s.repo.DeleteUser(userID)
 }
+
+// SendCoinGift transfers coins from the user to a friend within the daily gift limit
+func (s *UserService) SendCoinGift(ctx context.Context, userID, friendID int, req models.SendCoinGiftRequest) (*models.CoinGift, error) {
+	return s.repo.SendCoinGift(ctx, userID, friendID, req, config.Cfg.CoinGiftDailyLimit)
+}
@@ -0,0 +1,31 @@
+package middleware
+
+import (
+	"BecomeOverMan/internal/services"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ModeratorOnlyMiddleware пропускает только модераторов. Ставится после JWTAuthMiddleware.
+func ModeratorOnlyMiddleware(questService *services.QuestService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := GetUserID(c)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+
+		isModerator, err := questService.IsModerator(c.Request.Context(), userID)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if !isModerator {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": services.ErrNotModerator.Error()})
+			return
+		}
+
+		c.Next()
+	}
+}